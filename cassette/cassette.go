@@ -0,0 +1,329 @@
+// Package cassette records the HTTP interactions a test makes so that they
+// can be replayed later without a network connection or API keys.
+//
+// A Cassette is used as the HttpClient of whatever is under test. When
+// recording, every request is sent with a real client and the request and its
+// response are saved to a JSON file when the Cassette is closed. When
+// replaying, each request is answered with the recorded response of a
+// matching request instead. Requests match if they have the same method,
+// path, query and form body, along with the same values for any headers
+// listed in MatchHeaders. Other headers are ignored so that values such as
+// idempotency keys can differ between runs, and they are never saved, so API
+// keys don't end up in the file.
+//
+//	c := cassette.New(t, "testdata/TestCharge.json", cassette.Replay)
+//	defer c.Close()
+//	client := &stripe.Client{Key: "sk_test_123", HttpClient: c}
+//
+// JSON response bodies are saved as JSON rather than as strings, so they are
+// replayed with the same content but not necessarily the same whitespace.
+//
+// Replaying fails the test if a request has no matching interaction, or if
+// any recorded interactions were not used by the time the Cassette is
+// closed.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Mode determines whether a Cassette records or replays interactions.
+type Mode int
+
+const (
+	Replay Mode = iota
+	Record
+)
+
+// Request is the part of a recorded request that is used for matching.
+type Request struct {
+	Method string `json:"method"`
+	// Path is the request's path, followed by its query string with the
+	// parameters sorted by key.
+	Path string `json:"path"`
+	// Body is the request body. Form bodies have their parameters sorted by
+	// key.
+	Body string `json:"body,omitempty"`
+	// Header holds the request's values for the Cassette's MatchHeaders.
+	Header http.Header `json:"header,omitempty"`
+}
+
+func (r Request) String() string {
+	s := r.Method + " " + r.Path
+	if r.Body != "" {
+		s += fmt.Sprintf(" with body %q", r.Body)
+	}
+	keys := make([]string, 0, len(r.Header))
+	for k := range r.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s += fmt.Sprintf(" %s: %s", k, strings.Join(r.Header[k], ", "))
+	}
+	return s
+}
+
+// matches reports whether r and other are the same request.
+func (r Request) matches(other Request) bool {
+	return r.Method == other.Method && r.Path == other.Path && r.Body == other.Body &&
+		reflect.DeepEqual(r.Header, other.Header)
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// responseJSON is how a Response is saved. JSON objects and arrays are saved
+// as they are to keep cassettes readable, while any other body is saved as a
+// string.
+type responseJSON struct {
+	StatusCode int             `json:"status_code"`
+	Header     http.Header     `json:"header,omitempty"`
+	Body       json.RawMessage `json:"body"`
+}
+
+func (r Response) MarshalJSON() ([]byte, error) {
+	tmp := responseJSON{StatusCode: r.StatusCode, Header: r.Header}
+	trimmed := strings.TrimSpace(r.Body)
+	if json.Valid([]byte(trimmed)) && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) {
+		tmp.Body = json.RawMessage(trimmed)
+	} else {
+		body, err := json.Marshal(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		tmp.Body = body
+	}
+	return json.Marshal(tmp)
+}
+
+func (r *Response) UnmarshalJSON(data []byte) error {
+	var tmp responseJSON
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	r.StatusCode = tmp.StatusCode
+	r.Header = tmp.Header
+	r.Body = string(tmp.Body)
+	if strings.HasPrefix(r.Body, `"`) {
+		return json.Unmarshal(tmp.Body, &r.Body)
+	}
+	return nil
+}
+
+// Interaction is a request and the response it received.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is an HttpClient that records or replays interactions. It is safe
+// for concurrent use.
+type Cassette struct {
+	// HttpClient is used to send requests while recording and defaults to an
+	// http.Client.
+	HttpClient interface {
+		Do(*http.Request) (*http.Response, error)
+	}
+
+	// MatchHeaders are the names of request headers that are saved with each
+	// interaction and must have the same values for a request to match it,
+	// such as a header choosing an API version.
+	MatchHeaders []string
+
+	t    testing.TB
+	path string
+	mode Mode
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// New returns a Cassette that records interactions to, or replays them from,
+// the file at path. When replaying, t fails immediately if the file can't be
+// read.
+func New(t testing.TB, path string, mode Mode) *Cassette {
+	t.Helper()
+	c := &Cassette{t: t, path: path, mode: mode}
+	if mode == Record {
+		return c
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cassette: failed to read %s. err = %v", path, err)
+		return c
+	}
+	if err := json.Unmarshal(data, &c.interactions); err != nil {
+		t.Fatalf("cassette: failed to parse %s. err = %v", path, err)
+	}
+	c.used = make([]bool, len(c.interactions))
+	return c
+}
+
+// Do records or replays req.
+func (c *Cassette) Do(req *http.Request) (*http.Response, error) {
+	r, err := c.newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.mode == Record {
+		return c.record(req, r)
+	}
+	return c.replay(req, r)
+}
+
+func (c *Cassette) record(req *http.Request, r Request) (*http.Response, error) {
+	httpClient := c.HttpClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, Interaction{
+		Request: r,
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Header:     res.Header,
+			Body:       string(body),
+		},
+	})
+	return res, nil
+}
+
+// replay answers req with the first unused interaction that matches it, so
+// identical requests get their responses in the order they were recorded.
+func (c *Cassette) replay(req *http.Request, r Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, in := range c.interactions {
+		if c.used[i] || !in.Request.matches(r) {
+			continue
+		}
+		c.used[i] = true
+		header := in.Response.Header
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	err := fmt.Errorf("cassette: no unused interaction in %s matches %s", c.path, r)
+	for i, in := range c.interactions {
+		if !c.used[i] {
+			err = fmt.Errorf("%v; the next unused interaction is %s", err, in.Request)
+			break
+		}
+	}
+	// Errorf rather than Fatalf, as Do may not be called from the test's
+	// goroutine.
+	c.t.Errorf("%v", err)
+	return nil, err
+}
+
+// Close saves the recorded interactions when recording, or fails the test if
+// any interactions were not replayed.
+func (c *Cassette) Close() {
+	c.t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mode == Replay {
+		for i, in := range c.interactions {
+			if !c.used[i] {
+				c.t.Errorf("cassette: interaction %d in %s was not used: %s", i, c.path, in.Request)
+			}
+		}
+		return
+	}
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		c.t.Fatalf("cassette: failed to marshal interactions. err = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		c.t.Fatalf("cassette: failed to create %s. err = %v", filepath.Dir(c.path), err)
+	}
+	if err := ioutil.WriteFile(c.path, data, 0644); err != nil {
+		c.t.Fatalf("cassette: failed to write %s. err = %v", c.path, err)
+	}
+}
+
+// newRequest returns the Request used to match req. req's body is read and
+// replaced so that it can still be sent.
+func (c *Cassette) newRequest(req *http.Request) (Request, error) {
+	r := Request{
+		Method: req.Method,
+		Path:   req.URL.EscapedPath(),
+	}
+	for _, name := range c.MatchHeaders {
+		values := req.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		if r.Header == nil {
+			r.Header = make(http.Header)
+		}
+		r.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+	if req.URL.RawQuery != "" {
+		r.Path += "?" + sortedQuery(req.URL.RawQuery)
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return r, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return r, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.Body = string(body)
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		r.Body = sortedQuery(r.Body)
+	}
+	return r, nil
+}
+
+// sortedQuery returns the URL encoded parameters in raw sorted by key, or raw
+// itself if it can't be parsed.
+func sortedQuery(raw string) string {
+	v, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+	return v.Encode()
+}
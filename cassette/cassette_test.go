@@ -0,0 +1,217 @@
+package cassette_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joncalhoun/twg/cassette"
+)
+
+// fakeT records failures rather than failing the real test.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (ft *fakeT) Helper() {}
+
+func (ft *fakeT) Errorf(format string, args ...interface{}) {
+	ft.errors = append(ft.errors, fmt.Sprintf(format, args...))
+}
+
+func (ft *fakeT) Fatalf(format string, args ...interface{}) {
+	ft.Errorf(format, args...)
+}
+
+func get(t *testing.T, c *cassette.Cassette, path string) (int, string) {
+	req, err := http.NewRequest(http.MethodGet, "http://example.com"+path, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() err = %v; want nil", err)
+	}
+	return do(t, c, req)
+}
+
+func post(t *testing.T, c *cassette.Cassette, path string, form url.Values) (int, string) {
+	req, err := http.NewRequest(http.MethodPost, "http://example.com"+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatalf("NewRequest() err = %v; want nil", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return do(t, c, req)
+}
+
+func do(t *testing.T, c *cassette.Cassette, req *http.Request) (int, string) {
+	res, err := c.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() err = %v; want nil", err)
+	}
+	// JSON bodies are compacted, as cassettes don't preserve their whitespace.
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, body); err == nil {
+		body = compact.Bytes()
+	}
+	return res.StatusCode, string(body)
+}
+
+func TestCassette(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		r.ParseForm()
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "not found")
+			return
+		}
+		fmt.Fprintf(w, `{"request": %d, "name": %q}`, requests, r.Form.Get("name"))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rewrite := func(req *http.Request) {
+		req.URL.Host = strings.TrimPrefix(server.URL, "http://")
+	}
+
+	rec := cassette.New(t, path, cassette.Record)
+	rec.HttpClient = clientFunc(func(req *http.Request) (*http.Response, error) {
+		rewrite(req)
+		return http.DefaultClient.Do(req)
+	})
+	var recorded []string
+	for _, fn := range []func() (int, string){
+		func() (int, string) { return get(t, rec, "/items?b=2&a=1") },
+		func() (int, string) { return get(t, rec, "/items?b=2&a=1") },
+		func() (int, string) {
+			return post(t, rec, "/items", url.Values{"name": {"Jon"}, "age": {"30"}})
+		},
+		func() (int, string) { return get(t, rec, "/missing") },
+	} {
+		status, body := fn()
+		recorded = append(recorded, fmt.Sprintf("%d %s", status, body))
+	}
+	rec.Close()
+	if requests != 4 {
+		t.Fatalf("requests = %d; want 4", requests)
+	}
+
+	// Replaying must not touch the network, matches regardless of parameter
+	// order and answers identical requests in the order they were recorded.
+	server.Close()
+	c := cassette.New(t, path, cassette.Replay)
+	var replayed []string
+	for _, fn := range []func() (int, string){
+		func() (int, string) {
+			return post(t, c, "/items", url.Values{"age": {"30"}, "name": {"Jon"}})
+		},
+		func() (int, string) { return get(t, c, "/missing") },
+		func() (int, string) { return get(t, c, "/items?a=1&b=2") },
+		func() (int, string) { return get(t, c, "/items?b=2&a=1") },
+	} {
+		status, body := fn()
+		replayed = append(replayed, fmt.Sprintf("%d %s", status, body))
+	}
+	c.Close()
+	want := []string{recorded[2], recorded[3], recorded[0], recorded[1]}
+	for i := range want {
+		if replayed[i] != want[i] {
+			t.Errorf("response %d = %s; want %s", i, replayed[i], want[i])
+		}
+	}
+}
+
+func TestCassette_failures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec := cassette.New(t, path, cassette.Record)
+	rec.HttpClient = clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+		}, nil
+	})
+	get(t, rec, "/a")
+	get(t, rec, "/b")
+	rec.Close()
+
+	ft := &fakeT{}
+	c := cassette.New(ft, path, cassette.Replay)
+	if _, body := get(t, c, "/c"); !strings.Contains(body, "GET /c") {
+		t.Errorf("Do() err = %s; want it to name the unmatched request", body)
+	}
+	get(t, c, "/b")
+	c.Close()
+	want := []string{
+		"cassette: no unused interaction in " + path + " matches GET /c; the next unused interaction is GET /a",
+		"cassette: interaction 0 in " + path + " was not used: GET /a",
+	}
+	if fmt.Sprint(ft.errors) != fmt.Sprint(want) {
+		t.Errorf("errors = %q; want %q", ft.errors, want)
+	}
+
+	ft = &fakeT{}
+	cassette.New(ft, filepath.Join(t.TempDir(), "missing.json"), cassette.Replay)
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "failed to read") {
+		t.Errorf("errors = %q; want a read failure", ft.errors)
+	}
+}
+
+func TestCassette_matchHeaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	send := func(c *cassette.Cassette, version string) {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("Api-Version", version)
+		req.Header.Set("Authorization", "Bearer secret")
+		if res, err := c.Do(req); err == nil {
+			res.Body.Close()
+		}
+	}
+	rec := cassette.New(t, path, cassette.Record)
+	rec.MatchHeaders = []string{"api-version"}
+	rec.HttpClient = clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+		}, nil
+	})
+	send(rec, "2019-02-11")
+	rec.Close()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() err = %v; want nil", err)
+	}
+	if !strings.Contains(string(data), "2019-02-11") || strings.Contains(string(data), "secret") {
+		t.Errorf("cassette = %s; want only the matched header saved", data)
+	}
+
+	c := cassette.New(t, path, cassette.Replay)
+	c.MatchHeaders = []string{"Api-Version"}
+	send(c, "2019-02-11")
+	c.Close()
+
+	ft := &fakeT{}
+	c = cassette.New(ft, path, cassette.Replay)
+	c.MatchHeaders = []string{"Api-Version"}
+	send(c, "2018-09-24")
+	want := "cassette: no unused interaction in " + path + " matches GET /items Api-Version: 2018-09-24; " +
+		"the next unused interaction is GET /items Api-Version: 2019-02-11"
+	if len(ft.errors) != 1 || ft.errors[0] != want {
+		t.Errorf("errors = %q; want %q", ft.errors, want)
+	}
+}
+
+type clientFunc func(*http.Request) (*http.Response, error)
+
+func (fn clientFunc) Do(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
@@ -0,0 +1,169 @@
+package form
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// Builder generates HTML forms from Go structs like HTML does, but rather
+// than using a single template for every field it uses a template for each
+// type of input. Every rendered input, except for hidden ones, is then
+// wrapped in the Layout template.
+//
+// The zero value is ready to use and renders forms with the default
+// templates. Designers can theme individual controls by providing only the
+// templates they want to change:
+//
+//	b := form.Builder{
+//	  Inputs: map[string]*template.Template{
+//	    "select": template.Must(template.New("").Parse(`...`)),
+//	  },
+//	}
+//	html, err := b.HTML(order)
+type Builder struct {
+	// Inputs maps an input type, such as "text" or "select", to the template
+	// used to render inputs of that type. Each template is executed with the
+	// same data HTML passes to its template. Types without a template here
+	// use the default template for that type if there is one, and otherwise
+	// fall back to the "text" template.
+	Inputs map[string]*template.Template
+
+	// Layout wraps each rendered input. It is executed with the same data as
+	// the input templates along with the rendered input as .Input, which
+	// makes it the place to render labels and errors.
+	Layout *template.Template
+
+	// Translator translates labels, placeholders and option labels in the
+	// forms HTML renders, as well as the messages returned by Decode and
+	// Validate. It is optional and forms are rendered in English without one.
+	// WithLanguage picks a Translator for each request instead.
+	Translator Translator
+
+	// csrf is the hidden CSRF token field added by WithCSRF.
+	csrf *field
+}
+
+var (
+	// DefaultInputs are the input templates used when a Builder doesn't
+	// provide its own.
+	DefaultInputs = map[string]*template.Template{
+		"text": template.Must(template.New("text").Parse(
+			`<input type="{{.Type}}" id="{{.Name}}" name="{{.Name}}" placeholder="{{.Placeholder}}"{{with .Value}} value="{{.}}"{{end}}>`)),
+		"hidden": template.Must(template.New("hidden").Parse(
+			`<input type="hidden" name="{{.Name}}"{{with .Value}} value="{{.}}"{{end}}>`)),
+		"textarea": template.Must(template.New("textarea").Parse(
+			`<textarea id="{{.Name}}" name="{{.Name}}" placeholder="{{.Placeholder}}">{{.Value}}</textarea>`)),
+		"checkbox": template.Must(template.New("checkbox").Parse(
+			`<input type="checkbox" id="{{.Name}}" name="{{.Name}}"{{if .Value}} checked{{end}}>`)),
+		"select": template.Must(template.New("select").Parse(
+			`<select id="{{.Name}}" name="{{.Name}}">{{range .Options}}<option value="{{.Value}}"{{if .Selected}} selected{{end}}>{{.Label}}</option>{{end}}</select>`)),
+		"radio": template.Must(template.New("radio").Parse(
+			`{{range .Options}}<label><input type="radio" name="{{$.Name}}" value="{{.Value}}"{{if .Selected}} checked{{end}}> {{.Label}}</label>{{end}}`)),
+	}
+
+	// DefaultLayout is the layout template used when a Builder doesn't
+	// provide its own.
+	DefaultLayout = template.Must(template.New("layout").Parse(
+		`<div><label for="{{.Name}}">{{.Label}}</label>{{.Input}}{{range .Errors}}<p>{{.}}</p>{{end}}</div>`))
+)
+
+// layoutData is passed to the layout template.
+type layoutData struct {
+	field
+	Input template.HTML
+}
+
+// HTML is used to generate an HTML form from strct. Errors are attached to
+// fields exactly as they are by the HTML function.
+func (b *Builder) HTML(strct interface{}, errors ...FieldError) (template.HTML, error) {
+	fields, err := parseFields(strct)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if b.csrf != nil {
+		err = b.input("hidden").Execute(&sb, b.csrf)
+		if err != nil {
+			return "", err
+		}
+	}
+	for _, field := range fields {
+		field.translate(b.Translator)
+		field.setErrors(errors)
+		var input strings.Builder
+		err = b.input(field.Type).Execute(&input, field)
+		if err != nil {
+			return "", err
+		}
+		// Hidden inputs have nothing to display, so they don't get a layout.
+		if field.Type == "hidden" {
+			sb.WriteString(input.String())
+			continue
+		}
+		err = b.layout().Execute(&sb, layoutData{
+			field: field,
+			Input: template.HTML(input.String()),
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return template.HTML(sb.String()), nil
+}
+
+// WithCSRF returns a copy of b that adds a hidden input holding the CSRF
+// token for r to the start of every form it renders. The copy should only be
+// used to render forms for r.
+func (b *Builder) WithCSRF(c *CSRF, r *http.Request) *Builder {
+	cp := *b
+	cp.csrf = &field{
+		Name:  c.fieldName(),
+		Type:  "hidden",
+		Value: c.Token(r),
+	}
+	return &cp
+}
+
+// WithLanguage returns a copy of b whose Translator is the one in langs that
+// best matches r's Accept-Language header. If none of them match, b's own
+// Translator is kept. The copy should only be used to render forms for r.
+func (b *Builder) WithLanguage(langs Languages, r *http.Request) *Builder {
+	cp := *b
+	if tr := langs.ForRequest(r); tr != nil {
+		cp.Translator = tr
+	}
+	return &cp
+}
+
+// Decode is like the Decode function, but messages are translated with b's
+// Translator so that they match the forms b renders.
+func (b *Builder) Decode(r *http.Request, dst interface{}) ([]FieldError, error) {
+	return decode(r, dst, b.Translator)
+}
+
+// Validate is like the Validate function, but messages are translated with
+// b's Translator so that they match the forms b renders.
+func (b *Builder) Validate(strct interface{}) ([]FieldError, error) {
+	return validate(strct, b.Translator)
+}
+
+func (b *Builder) input(typ string) *template.Template {
+	if t, ok := b.Inputs[typ]; ok {
+		return t
+	}
+	if t, ok := DefaultInputs[typ]; ok {
+		return t
+	}
+	if t, ok := b.Inputs["text"]; ok {
+		return t
+	}
+	return DefaultInputs["text"]
+}
+
+func (b *Builder) layout() *template.Template {
+	if b.Layout != nil {
+		return b.Layout
+	}
+	return DefaultLayout
+}
@@ -0,0 +1,89 @@
+package form_test
+
+import (
+	"html/template"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/joncalhoun/twg/form"
+)
+
+func TestBuilder_HTML(t *testing.T) {
+	type order struct {
+		ID      int    `form:"type=hidden"`
+		Name    string `form:"label=Full Name"`
+		Email   string `form:"type=email"`
+		Country string `form:"options=US,CA,MX"`
+		Size    string `form:"type=radio;options=S,M,L"`
+		Gift    bool
+		Notes   string `form:"type=textarea"`
+	}
+	strct := order{
+		ID:      123,
+		Name:    "Michael Scott",
+		Country: "CA",
+		Size:    "M",
+		Gift:    true,
+	}
+	errors := []form.FieldError{
+		{Field: "Email", Error: "Email is required"},
+	}
+
+	tests := map[string]struct {
+		builder form.Builder
+		want    string
+	}{
+		"Default templates": {
+			builder: form.Builder{},
+			want:    "TestBuilder_defaults.golden",
+		},
+		"Custom templates": {
+			builder: form.Builder{
+				Inputs: map[string]*template.Template{
+					"text":   template.Must(template.New("").Parse(`<input class="themed" type="{{.Type}}" name="{{.Name}}"{{with .Value}} value="{{.}}"{{end}}>`)),
+					"select": template.Must(template.New("").Parse(`<select class="themed" name="{{.Name}}">{{range .Options}}<option{{if .Selected}} selected{{end}}>{{.Value}}</option>{{end}}</select>`)),
+				},
+				Layout: template.Must(template.New("").Parse(`
+<div class="field{{with .Errors}} invalid{{end}}">
+	<label>{{.Label}}</label>
+	{{.Input}}
+	{{- range .Errors}}
+	<p class="error">{{.}}</p>
+	{{- end}}
+</div>`)),
+			},
+			want: "TestBuilder_custom.golden",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.builder.HTML(strct, errors...)
+			if err != nil {
+				t.Fatalf("HTML() err = %v; want nil", err)
+			}
+			gotFilename := strings.Replace(tc.want, ".golden", ".got", 1)
+			os.Remove(gotFilename)
+			if updateFlag {
+				writeFile(t, tc.want, string(got))
+				t.Logf("Updated golden file %s", tc.want)
+			}
+			want := template.HTML(readFile(t, tc.want))
+			if got != want {
+				t.Errorf("HTML() - results do not match golden file.")
+				writeFile(t, gotFilename, string(got))
+				t.Errorf("  To compare run: diff %s %s", gotFilename, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_HTML_templateError(t *testing.T) {
+	b := form.Builder{
+		Layout: template.Must(template.New("").Parse(`{{.Missing}}`)),
+	}
+	_, err := b.HTML(struct{ Name string }{})
+	if err == nil {
+		t.Errorf("HTML() err = nil; want an error")
+	}
+}
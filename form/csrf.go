@@ -0,0 +1,164 @@
+package form
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// The defaults used by CSRF, along with the header JavaScript requests can
+// use to submit a token instead of a form field.
+const (
+	DefaultCSRFCookieName = "csrf"
+	DefaultCSRFFieldName  = "csrf_token"
+	CSRFHeader            = "X-CSRF-Token"
+)
+
+// CSRF protects forms against cross-site request forgery. Its Middleware
+// gives every visitor a random value stored in a cookie, and forms include a
+// token derived from that value by signing it with Secret. Requests that
+// could change something, which is anything other than GET, HEAD, OPTIONS
+// or TRACE, are rejected unless they submit the token matching their cookie.
+//
+// Forms rendered by a Builder include the token when the Builder is returned
+// by WithCSRF. Anywhere else, the HTML method renders the hidden input.
+type CSRF struct {
+	// Secret is used to sign tokens and must be set. It should be long,
+	// random and kept private.
+	Secret []byte
+
+	// CookieName and FieldName default to DefaultCSRFCookieName and
+	// DefaultCSRFFieldName. Tokens can also be submitted in the CSRFHeader
+	// header, which is useful for JavaScript requests.
+	CookieName string
+	FieldName  string
+
+	// Invalid is called instead of the next handler when a request's token is
+	// missing or doesn't match its cookie. It is given a FieldError for the
+	// token field so that a form can be re-rendered with it. By default a 403
+	// Forbidden response is sent.
+	Invalid func(w http.ResponseWriter, r *http.Request, fe FieldError)
+}
+
+type csrfContextKey struct{}
+
+// Middleware verifies the token submitted with every unsafe request before
+// calling next. It also issues a cookie to visitors that don't have one yet
+// so that the forms next renders can include a token.
+func (c *CSRF) Middleware(next http.Handler) http.Handler {
+	if len(c.Secret) == 0 {
+		panic("form: CSRF.Secret must be set")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		default:
+			if !c.valid(r) {
+				c.invalid(w, r)
+				return
+			}
+		}
+		if cookie, err := r.Cookie(c.cookieName()); err != nil || cookie.Value == "" {
+			value, err := randomValue()
+			if err != nil {
+				http.Error(w, "Something went wrong", http.StatusInternalServerError)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     c.cookieName(),
+				Value:    value,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			// The cookie won't be sent back until the next request, so it is
+			// stored in the context for anything rendering a form now.
+			r = r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, value))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Token returns the CSRF token that forms rendered for r should submit. It
+// returns an empty string if r has not been through Middleware and has no
+// cookie.
+func (c *CSRF) Token(r *http.Request) string {
+	value, _ := r.Context().Value(csrfContextKey{}).(string)
+	if value == "" {
+		cookie, err := r.Cookie(c.cookieName())
+		if err != nil {
+			return ""
+		}
+		value = cookie.Value
+	}
+	return c.sign(value)
+}
+
+// HTML returns a hidden input holding the CSRF token for r. It is intended
+// to be used in templates that don't use a Builder.
+func (c *CSRF) HTML(r *http.Request) template.HTML {
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`,
+		template.HTMLEscapeString(c.fieldName()), template.HTMLEscapeString(c.Token(r))))
+}
+
+// FieldError returns the FieldError used to describe a missing or invalid
+// CSRF token.
+func (c *CSRF) FieldError() FieldError {
+	return FieldError{
+		Field: c.fieldName(),
+		Error: "The form has expired. Please try submitting it again.",
+	}
+}
+
+func (c *CSRF) valid(r *http.Request) bool {
+	cookie, err := r.Cookie(c.cookieName())
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	submitted := r.Header.Get(CSRFHeader)
+	if submitted == "" {
+		submitted = r.PostFormValue(c.fieldName())
+	}
+	return hmac.Equal([]byte(submitted), []byte(c.sign(cookie.Value)))
+}
+
+func (c *CSRF) invalid(w http.ResponseWriter, r *http.Request) {
+	if c.Invalid != nil {
+		c.Invalid(w, r, c.FieldError())
+		return
+	}
+	http.Error(w, c.FieldError().Error, http.StatusForbidden)
+}
+
+func (c *CSRF) sign(value string) string {
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *CSRF) cookieName() string {
+	if c.CookieName == "" {
+		return DefaultCSRFCookieName
+	}
+	return c.CookieName
+}
+
+func (c *CSRF) fieldName() string {
+	if c.FieldName == "" {
+		return DefaultCSRFFieldName
+	}
+	return c.FieldName
+}
+
+func randomValue() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
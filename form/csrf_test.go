@@ -0,0 +1,144 @@
+package form_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/joncalhoun/twg/form"
+)
+
+func TestCSRF_Middleware(t *testing.T) {
+	csrf := &form.CSRF{Secret: []byte("test-secret")}
+	var token string
+	handler := csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = csrf.Token(r)
+		fmt.Fprint(w, "ok")
+	}))
+
+	// A GET request should be allowed and issue a cookie along with a token.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	res := w.Result()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("GET status = %d; want %d", res.StatusCode, http.StatusOK)
+	}
+	cookies := res.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != form.DefaultCSRFCookieName {
+		t.Fatalf("GET cookies = %v; want a %s cookie", cookies, form.DefaultCSRFCookieName)
+	}
+	cookie := cookies[0]
+	if token == "" {
+		t.Fatalf("Token() = %q; want a token", token)
+	}
+
+	post := func(cookie *http.Cookie, values url.Values, header string) *http.Response {
+		r := postForm(values)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		if header != "" {
+			r.Header.Set(form.CSRFHeader, header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Result()
+	}
+	tests := map[string]struct {
+		cookie *http.Cookie
+		values url.Values
+		header string
+		want   int
+	}{
+		"valid token": {
+			cookie: cookie,
+			values: url.Values{form.DefaultCSRFFieldName: {token}},
+			want:   http.StatusOK,
+		},
+		"valid token in header": {
+			cookie: cookie,
+			header: token,
+			want:   http.StatusOK,
+		},
+		"missing token": {
+			cookie: cookie,
+			want:   http.StatusForbidden,
+		},
+		"invalid token": {
+			cookie: cookie,
+			values: url.Values{form.DefaultCSRFFieldName: {"forged"}},
+			want:   http.StatusForbidden,
+		},
+		"missing cookie": {
+			values: url.Values{form.DefaultCSRFFieldName: {token}},
+			want:   http.StatusForbidden,
+		},
+		"token for another cookie": {
+			cookie: &http.Cookie{Name: form.DefaultCSRFCookieName, Value: "someone-else"},
+			values: url.Values{form.DefaultCSRFFieldName: {token}},
+			want:   http.StatusForbidden,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := post(tc.cookie, tc.values, tc.header)
+			if res.StatusCode != tc.want {
+				t.Errorf("POST status = %d; want %d", res.StatusCode, tc.want)
+			}
+		})
+	}
+}
+
+func TestCSRF_Invalid(t *testing.T) {
+	var got form.FieldError
+	csrf := &form.CSRF{
+		Secret: []byte("test-secret"),
+		Invalid: func(w http.ResponseWriter, r *http.Request, fe form.FieldError) {
+			got = fe
+			http.Error(w, fe.Error, http.StatusUnprocessableEntity)
+		},
+	}
+	handler := csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("next handler called with an invalid token")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, postForm(url.Values{}))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d; want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if got != csrf.FieldError() {
+		t.Errorf("Invalid() FieldError = %v; want %v", got, csrf.FieldError())
+	}
+}
+
+func TestBuilder_WithCSRF(t *testing.T) {
+	csrf := &form.CSRF{Secret: []byte("test-secret"), FieldName: "authenticity_token"}
+	var b form.Builder
+	var got string
+	handler := csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		html, err := b.WithCSRF(csrf, r).HTML(struct{ Name string }{})
+		if err != nil {
+			t.Fatalf("HTML() err = %v; want nil", err)
+		}
+		got = string(html)
+		if want := string(csrf.HTML(r)); !strings.HasPrefix(got, want) {
+			t.Errorf("HTML() = %s; want prefix %s", got, want)
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(got, `name="authenticity_token"`) {
+		t.Errorf("HTML() = %s; want a hidden authenticity_token input", got)
+	}
+
+	// The original Builder should not be changed.
+	html, err := b.HTML(struct{ Name string }{})
+	if err != nil {
+		t.Fatalf("HTML() err = %v; want nil", err)
+	}
+	if strings.Contains(string(html), "authenticity_token") {
+		t.Errorf("HTML() = %s; want no CSRF input", html)
+	}
+}
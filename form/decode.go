@@ -0,0 +1,406 @@
+package form
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeLayouts are the layouts we attempt to parse time.Time values with, in
+// order. The first two match what browsers submit for date and
+// datetime-local inputs.
+var timeLayouts = []string{
+	"2006-01-02",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+// Decode is the inverse of HTML. It parses the form submitted with r and
+// uses the values to populate dst, which must be a non-nil pointer to a
+// struct. Field names are determined exactly as they are in HTML, so nested
+// structs are read from names like "Address.City" and the name struct tag
+// is respected. Fields promoted from embedded structs are read from their own
+// names, eg "ID" rather than "Base.ID", and types implementing
+// FormUnmarshaler decode themselves.
+//
+// Fields missing from the form are left as they are, so dst can hold values
+// loaded from elsewhere, such as a database. Checkboxes are the exception:
+// browsers don't submit them when they are unchecked, so if any values were
+// submitted, checkbox fields missing from the form are set to false.
+//
+// Values that cannot be converted into their field's type are returned as
+// FieldErrors so that they can be passed straight back into HTML. The error
+// is only non-nil when dst has an invalid struct tag or the request's form
+// could not be parsed.
+//
+// Messages are in English. Use a Builder's Decode method to translate them
+// with its Translator.
+func Decode(r *http.Request, dst interface{}) ([]FieldError, error) {
+	return decode(r, dst, nil)
+}
+
+// decode is Decode with messages and labels translated by tr, which may be
+// nil.
+func decode(r *http.Request, dst interface{}, tr Translator) ([]FieldError, error) {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		panic("form: invalid value; only non-nil pointers to structs are supported")
+	}
+	p, err := planFor(rv.Elem().Type())
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	_, errs := p.decode(rv.Elem(), r.Form, nil, 0)
+	var errors []FieldError
+	for _, e := range errs {
+		errors = append(errors, e.fieldError(tr))
+	}
+	return errors, nil
+}
+
+// decodeError is a value submitted for the input named field, labelled
+// label, that setValue couldn't convert.
+type decodeError struct {
+	field, label string
+	err          error
+}
+
+// fieldError returns e as a FieldError with its message translated by tr.
+// The error is treated as a message format like those used by Validate, eg
+// "%[1]s must be a whole number", so that Translators can translate it.
+func (e decodeError) fieldError(tr Translator) FieldError {
+	format := "%[1]s " + strings.ReplaceAll(e.err.Error(), "%", "%%")
+	return FieldError{
+		Field: e.field,
+		Error: fmt.Sprintf(translate(tr, format), translate(tr, e.label)),
+	}
+}
+
+// maxIndex is the largest slice index Decode will accept. It prevents a
+// request with a name like "Addresses.99999999.City" from allocating an
+// enormous slice.
+const maxIndex = 1000
+
+// decode populates rv, a struct of the type p was built for, with any values
+// in form. It reports whether any fields were present in form so that nil
+// pointers are only allocated when they need to be. parentNames and scope
+// are used to name inputs just as they are by fields.
+func (p *structPlan) decode(rv reflect.Value, form url.Values, parentNames []string, scope int) (bool, []decodeError) {
+	var set bool
+	var errors []decodeError
+	for i := range p.fieldPlans {
+		fp := &p.fieldPlans[i]
+		names := append(parentNames[:len(parentNames):len(parentNames)], fp.name)
+		var fieldSet bool
+		var fieldErrors []decodeError
+		switch fp.kind {
+		case structKind:
+			fieldSet, fieldErrors = fp.nested.decodeStruct(settableField(rv, fp.index), form, names, scope)
+		case structsKind, valuesKind, mapKind:
+			fieldSet, fieldErrors = fp.decodeCollection(settableField(rv, fp.index), form, names, scope)
+		default:
+			name := fp.inputName(names, scope)
+			values, ok := form[name]
+			if !ok || len(values) == 0 {
+				// Browsers don't submit unchecked checkboxes, so one that is
+				// missing from a submitted form was unchecked.
+				if fp.input.Type == "checkbox" && len(form) > 0 {
+					clearField(rv, fp.index)
+				}
+				continue
+			}
+			fieldSet = true
+			if err := setValue(settableField(rv, fp.index), values[0]); err != nil {
+				fieldErrors = []decodeError{{
+					field: name,
+					label: fp.input.Label,
+					err:   err,
+				}}
+			}
+		}
+		set = set || fieldSet
+		errors = append(errors, fieldErrors...)
+	}
+	return set, errors
+}
+
+// settableField returns the field of rv with the index sequence index,
+// allocating any nil pointers to embedded structs on the way.
+func settableField(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv
+}
+
+// clearField sets the field of rv with the index sequence index to its zero
+// value. Nil pointers to embedded structs are left alone, as their fields
+// are zero already.
+func clearField(rv reflect.Value, index []int) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	rv.Set(reflect.Zero(rv.Type()))
+}
+
+// decodeStruct decodes into rv, which is either a struct or a pointer to one.
+// Nil pointers are only allocated if the struct had a field present in form.
+func (p *structPlan) decodeStruct(rv reflect.Value, form url.Values, names []string, scope int) (bool, []decodeError) {
+	if rv.Kind() != reflect.Ptr {
+		return p.decode(rv, form, names, scope)
+	}
+	nested := reflect.New(rv.Type().Elem())
+	if !rv.IsNil() {
+		nested.Elem().Set(rv.Elem())
+	}
+	set, errors := p.decode(nested.Elem(), form, names, scope)
+	if set {
+		rv.Set(nested)
+	}
+	return set, errors
+}
+
+// decodeCollection decodes into rv, a slice, array or map, or a pointer to
+// one of those.
+func (fp *fieldPlan) decodeCollection(rv reflect.Value, form url.Values, names []string, scope int) (bool, []decodeError) {
+	if rv.Kind() == reflect.Ptr {
+		target := reflect.New(rv.Type().Elem())
+		if !rv.IsNil() {
+			target.Elem().Set(rv.Elem())
+		}
+		set, errors := fp.decodeCollection(target.Elem(), form, names, scope)
+		if set {
+			rv.Set(target)
+		}
+		return set, errors
+	}
+	switch fp.kind {
+	case structsKind:
+		return fp.decodeStructs(rv, form, names)
+	case valuesKind:
+		name := fp.inputName(names, scope)
+		return fp.decodeValues(rv, form[name+"[]"], name+"[]")
+	default:
+		return fp.decodeMap(rv, form, names)
+	}
+}
+
+// decodeStructs decodes into rv, a slice or array of structs, using indexed
+// names like "Addresses.0.City". Slices grow to fit the largest index present
+// in form, while indexes past the end of an array are ignored.
+func (fp *fieldPlan) decodeStructs(rv reflect.Value, form url.Values, names []string) (bool, []decodeError) {
+	prefix := strings.Join(names, ".") + "."
+	last := -1
+	for key := range form {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		index := strings.SplitN(strings.TrimPrefix(key, prefix), ".", 2)[0]
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || i >= maxIndex {
+			continue
+		}
+		if i > last {
+			last = i
+		}
+	}
+	if last < 0 {
+		return false, nil
+	}
+	if rv.Kind() == reflect.Slice && last >= rv.Len() {
+		grown := reflect.MakeSlice(rv.Type(), last+1, last+1)
+		reflect.Copy(grown, rv)
+		rv.Set(grown)
+	}
+	var set bool
+	var errors []decodeError
+	for i := 0; i <= last && i < rv.Len(); i++ {
+		elemSet, elemErrors := fp.nested.decodeStruct(rv.Index(i), form, append(names, strconv.Itoa(i)), len(names)+1)
+		set = set || elemSet
+		errors = append(errors, elemErrors...)
+	}
+	return set, errors
+}
+
+// decodeValues decodes values into rv, a slice or array of anything other
+// than structs. Slices are replaced so that they have one element per value,
+// while any values that don't fit in an array are ignored.
+func (fp *fieldPlan) decodeValues(rv reflect.Value, values []string, name string) (bool, []decodeError) {
+	if values == nil {
+		return false, nil
+	}
+	if rv.Kind() == reflect.Slice {
+		rv.Set(reflect.MakeSlice(rv.Type(), len(values), len(values)))
+	}
+	var errors []decodeError
+	for i := 0; i < len(values) && i < rv.Len(); i++ {
+		if err := setValue(rv.Index(i), values[i]); err != nil {
+			errors = append(errors, decodeError{
+				field: name,
+				label: fp.input.Label,
+				err:   err,
+			})
+		}
+	}
+	return true, errors
+}
+
+// decodeMap decodes into rv, a map, using names like "Meta.color" where the
+// last part of the name is the key. Maps of structs use names like
+// "Addresses.home.City" instead. Entries are added to the map, but existing
+// entries that are missing from form are kept.
+func (fp *fieldPlan) decodeMap(rv reflect.Value, form url.Values, names []string) (bool, []decodeError) {
+	t := rv.Type()
+	prefix := strings.Join(names, ".") + "."
+	var keys []string
+	seen := make(map[string]bool)
+	for key := range form {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		k := strings.TrimPrefix(key, prefix)
+		if fp.nested != nil {
+			k = strings.SplitN(k, ".", 2)[0]
+		}
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return false, nil
+	}
+	// Sorting keeps the order of any errors consistent.
+	sort.Strings(keys)
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(t))
+	}
+	var errors []decodeError
+	for _, k := range keys {
+		label := k
+		if fp.labelTag {
+			label = fp.input.Label
+		}
+		key := reflect.New(t.Key()).Elem()
+		if err := setValue(key, k); err != nil {
+			errors = append(errors, decodeError{
+				field: prefix + k,
+				label: label,
+				err:   err,
+			})
+			continue
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if existing := rv.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if fp.nested != nil {
+			_, elemErrors := fp.nested.decodeStruct(elem, form, append(names, k), len(names)+1)
+			errors = append(errors, elemErrors...)
+		} else if err := setValue(elem, form[prefix+k][0]); err != nil {
+			errors = append(errors, decodeError{
+				field: prefix + k,
+				label: label,
+				err:   err,
+			})
+			continue
+		}
+		rv.SetMapIndex(key, elem)
+	}
+	return true, errors
+}
+
+// setValue converts raw into the type of rv and stores it. Empty values reset
+// rv to its zero value so that clearing an input clears the field. Errors
+// returned by setValue describe what the value must be and are intended to
+// be prefixed with the field's name.
+func setValue(rv reflect.Value, raw string) error {
+	if rv.Kind() == reflect.Ptr {
+		if raw == "" {
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		v := reflect.New(rv.Type().Elem())
+		if err := setValue(v.Elem(), raw); err != nil {
+			return err
+		}
+		rv.Set(v)
+		return nil
+	}
+	if raw == "" {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+	if u, ok := asInterface(rv, formUnmarshalerType); ok {
+		return u.(FormUnmarshaler).UnmarshalForm(raw)
+	}
+	if rv.Type() == timeType {
+		for _, layout := range timeLayouts {
+			t, err := time.Parse(layout, raw)
+			if err == nil {
+				rv.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("must be a valid date")
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(raw)
+	case reflect.Bool:
+		// Checkboxes are submitted with a value of "on" by default.
+		if raw == "on" {
+			rv.SetBool(true)
+			return nil
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a whole number")
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a positive whole number")
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		rv.SetFloat(n)
+	default:
+		return fmt.Errorf("has an unsupported type")
+	}
+	return nil
+}
@@ -0,0 +1,447 @@
+package form_test
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joncalhoun/twg/form"
+)
+
+func postForm(values url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestDecode(t *testing.T) {
+	type address struct {
+		Street string
+		Zip    int
+	}
+	type order struct {
+		Name     string
+		Email    string `form:"name=email_address"`
+		Quantity int
+		Price    float64
+		Gift     bool
+		Notes    *string
+		Ships    time.Time
+		Address  address
+		Billing  *address
+		Password string `form:"-"`
+		internal string
+	}
+	notes := "leave at the door"
+
+	tests := map[string]struct {
+		values url.Values
+		want   order
+	}{
+		"Empty form": {
+			values: url.Values{},
+			want:   order{},
+		},
+		"Strings and name tags": {
+			values: url.Values{
+				"Name":          {"Michael Scott"},
+				"email_address": {"michael@dundermifflin.com"},
+			},
+			want: order{
+				Name:  "Michael Scott",
+				Email: "michael@dundermifflin.com",
+			},
+		},
+		"Numbers and bools": {
+			values: url.Values{
+				"Quantity": {"3"},
+				"Price":    {"12.50"},
+				"Gift":     {"on"},
+			},
+			want: order{
+				Quantity: 3,
+				Price:    12.5,
+				Gift:     true,
+			},
+		},
+		"Pointers and times": {
+			values: url.Values{
+				"Notes": {notes},
+				"Ships": {"2018-11-05"},
+			},
+			want: order{
+				Notes: &notes,
+				Ships: time.Date(2018, 11, 5, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"Nested structs": {
+			values: url.Values{
+				"Address.Street": {"123 Fake St"},
+				"Address.Zip":    {"90210"},
+				"Billing.Zip":    {"12345"},
+			},
+			want: order{
+				Address: address{Street: "123 Fake St", Zip: 90210},
+				Billing: &address{Zip: 12345},
+			},
+		},
+		"Unexported and skipped fields are ignored": {
+			values: url.Values{
+				"internal": {"nope"},
+				"Password": {"hunter2"},
+			},
+			want: order{},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got order
+			errs, err := form.Decode(postForm(tc.values), &got)
+			if err != nil {
+				t.Fatalf("Decode() err = %v; want nil", err)
+			}
+			if len(errs) != 0 {
+				t.Errorf("Decode() field errors = %v; want none", errs)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Decode() = %+v; want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecode_uncheckedCheckboxes(t *testing.T) {
+	type order struct {
+		Name    string
+		Gift    bool
+		Address *struct {
+			Residential bool
+		}
+	}
+	tests := map[string]struct {
+		values url.Values
+		want   bool
+	}{
+		"unchecked": {
+			values: url.Values{"Name": {"Michael Scott"}},
+			want:   false,
+		},
+		"checked": {
+			values: url.Values{"Name": {"Michael Scott"}, "Gift": {"on"}},
+			want:   true,
+		},
+		"nothing submitted": {
+			values: url.Values{},
+			want:   true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := order{Name: "Jim Halpert", Gift: true}
+			errs, err := form.Decode(postForm(tc.values), &got)
+			if err != nil || len(errs) != 0 {
+				t.Fatalf("Decode() = %v, %v; want no errors", errs, err)
+			}
+			if got.Gift != tc.want {
+				t.Errorf("Gift = %t; want %t", got.Gift, tc.want)
+			}
+			if got.Address != nil {
+				t.Errorf("Address = %+v; want nil", got.Address)
+			}
+		})
+	}
+}
+
+func TestDecode_collections(t *testing.T) {
+	type line struct {
+		Item     string
+		Quantity int
+	}
+	type order struct {
+		Lines  []line
+		Ships  []*line
+		Tags   []string
+		Scores [2]int
+		Meta   map[string]string
+		Counts map[string]int
+		ByName map[string]line
+	}
+
+	tests := map[string]struct {
+		start  order
+		values url.Values
+		want   order
+	}{
+		"Slices of structs": {
+			values: url.Values{
+				"Lines.0.Item":     {"Shirt"},
+				"Lines.0.Quantity": {"2"},
+				"Lines.2.Item":     {"Hat"},
+				"Ships.1.Item":     {"Mug"},
+			},
+			want: order{
+				Lines: []line{{Item: "Shirt", Quantity: 2}, {}, {Item: "Hat"}},
+				Ships: []*line{nil, {Item: "Mug"}},
+			},
+		},
+		"Existing slice elements are updated": {
+			start: order{
+				Lines: []line{{Item: "Shirt", Quantity: 2}, {Item: "Hat", Quantity: 1}},
+			},
+			values: url.Values{
+				"Lines.1.Quantity": {"3"},
+			},
+			want: order{
+				Lines: []line{{Item: "Shirt", Quantity: 2}, {Item: "Hat", Quantity: 3}},
+			},
+		},
+		"Slices and arrays of other types": {
+			start: order{
+				Tags: []string{"old"},
+			},
+			values: url.Values{
+				"Tags[]":   {"go", "testing"},
+				"Scores[]": {"1", "2", "3"},
+			},
+			want: order{
+				Tags:   []string{"go", "testing"},
+				Scores: [2]int{1, 2},
+			},
+		},
+		"Maps": {
+			start: order{
+				Meta: map[string]string{"size": "L"},
+			},
+			values: url.Values{
+				"Meta.color":           {"blue"},
+				"Counts.shirts":        {"4"},
+				"ByName.home.Item":     {"Mug"},
+				"ByName.home.Quantity": {"1"},
+			},
+			want: order{
+				Meta:   map[string]string{"size": "L", "color": "blue"},
+				Counts: map[string]int{"shirts": 4},
+				ByName: map[string]line{"home": {Item: "Mug", Quantity: 1}},
+			},
+		},
+		"Indexes that are too large are ignored": {
+			values: url.Values{
+				"Lines.99999999.Item": {"Shirt"},
+			},
+			want: order{},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.start
+			errs, err := form.Decode(postForm(tc.values), &got)
+			if err != nil {
+				t.Fatalf("Decode() err = %v; want nil", err)
+			}
+			if len(errs) != 0 {
+				t.Errorf("Decode() field errors = %v; want none", errs)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Decode() = %+v; want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecode_fieldErrors(t *testing.T) {
+	var got struct {
+		Name     string
+		Quantity int `form:"label=How many?"`
+		Price    float64
+		Gift     bool
+		Ships    time.Time
+		Address  struct {
+			Zip uint `form:"name=zip"`
+		}
+		Tags  []int
+		Stock map[int]int
+	}
+	values := url.Values{
+		"Name":     {"Michael Scott"},
+		"Quantity": {"a few"},
+		"Price":    {"cheap"},
+		"Gift":     {"maybe"},
+		"Ships":    {"tomorrow"},
+		"zip":      {"-1"},
+		"Tags[]":   {"1", "two"},
+		"Stock.x":  {"1"},
+		"Stock.2":  {"many"},
+	}
+	errs, err := form.Decode(postForm(values), &got)
+	if err != nil {
+		t.Fatalf("Decode() err = %v; want nil", err)
+	}
+	want := []form.FieldError{
+		{Field: "Quantity", Error: "How many? must be a whole number"},
+		{Field: "Price", Error: "Price must be a number"},
+		{Field: "Gift", Error: "Gift must be true or false"},
+		{Field: "Ships", Error: "Ships must be a valid date"},
+		{Field: "zip", Error: "Zip must be a positive whole number"},
+		{Field: "Tags[]", Error: "Tags must be a whole number"},
+		{Field: "Stock.2", Error: "2 must be a whole number"},
+		{Field: "Stock.x", Error: "x must be a whole number"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("Decode() field errors = %v; want %v", errs, want)
+	}
+	if got.Name != "Michael Scott" {
+		t.Errorf("Name = %q; want %q", got.Name, "Michael Scott")
+	}
+}
+
+// hexColour decodes itself from values like "#ff8000".
+type hexColour struct {
+	R, G, B uint8
+}
+
+func (c hexColour) FormValue() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (c *hexColour) UnmarshalForm(value string) error {
+	if _, err := fmt.Sscanf(value, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return errors.New("must be a colour like #ff8000")
+	}
+	return nil
+}
+
+type Timestamps struct {
+	Created time.Time
+}
+
+type owner struct {
+	Owner string
+}
+
+func TestDecode_embeddedAndCustom(t *testing.T) {
+	type product struct {
+		*Timestamps
+		owner
+		Name    string
+		Colour  hexColour
+		Colours []hexColour
+	}
+	values := url.Values{
+		"Created":   {"2018-11-05T09:30"},
+		"Owner":     {"Dwight"},
+		"Name":      {"Mug"},
+		"Colour":    {"#ff8000"},
+		"Colours[]": {"#000001", "#000002"},
+	}
+	var got product
+	errs, err := form.Decode(postForm(values), &got)
+	if err != nil {
+		t.Fatalf("Decode() err = %v; want nil", err)
+	}
+	if len(errs) != 0 {
+		t.Errorf("Decode() field errors = %v; want none", errs)
+	}
+	want := product{
+		Timestamps: &Timestamps{Created: time.Date(2018, 11, 5, 9, 30, 0, 0, time.UTC)},
+		owner:      owner{Owner: "Dwight"},
+		Name:       "Mug",
+		Colour:     hexColour{R: 255, G: 128},
+		Colours:    []hexColour{{B: 1}, {B: 2}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %+v; want %+v", got, want)
+	}
+
+	errs, err = form.Decode(postForm(url.Values{"Colour": {"orange"}}), &got)
+	if err != nil {
+		t.Fatalf("Decode() err = %v; want nil", err)
+	}
+	wantErrs := []form.FieldError{
+		{Field: "Colour", Error: "Colour must be a colour like #ff8000"},
+	}
+	if !reflect.DeepEqual(errs, wantErrs) {
+		t.Errorf("Decode() field errors = %v; want %v", errs, wantErrs)
+	}
+}
+
+func TestDecode_invalidTags(t *testing.T) {
+	var dst struct {
+		Lines []struct {
+			Quantity int `form:"min=one"`
+		}
+	}
+	_, err := form.Decode(postForm(url.Values{}), &dst)
+	if err == nil {
+		t.Errorf("Decode() err = nil; want an error")
+	}
+}
+
+func TestDecode_invalidTypes(t *testing.T) {
+	var nilStructPtr *struct{ Name string }
+	tests := map[string]interface{}{
+		"struct":         struct{ Name string }{},
+		"string pointer": new(string),
+		"nil pointer":    nilStructPtr,
+		"nil":            nil,
+	}
+	for name, dst := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if err := recover(); err == nil {
+					t.Errorf("Decode(%v) did not panic", dst)
+				}
+			}()
+			form.Decode(postForm(url.Values{}), dst)
+		})
+	}
+}
+
+func TestDecode_roundTrip(t *testing.T) {
+	type line struct {
+		Item  string
+		Count int `form:"name=count"`
+	}
+	type order struct {
+		Email string `form:"name=email_address"`
+		Lines []line
+		Gifts map[string]line
+	}
+	want := order{
+		Email: "michael@dundermifflin.com",
+		Lines: []line{{"a", 1}, {"b", 2}},
+		Gifts: map[string]line{"jim": {"mug", 3}, "pam": {"shirt", 4}},
+	}
+	tpl := template.Must(template.New("").Parse("{{.Name}}\t{{.Value}}\n"))
+	html, err := form.HTML(tpl, want)
+	if err != nil {
+		t.Fatalf("HTML() err = %v; want nil", err)
+	}
+	values := url.Values{}
+	var names []string
+	for _, input := range strings.Split(strings.TrimSpace(string(html)), "\n") {
+		parts := strings.SplitN(input, "\t", 2)
+		values.Add(parts[0], parts[1])
+		names = append(names, parts[0])
+	}
+	wantNames := []string{"email_address", "Lines.0.Item", "Lines.0.count", "Lines.1.Item", "Lines.1.count",
+		"Gifts.jim.Item", "Gifts.jim.count", "Gifts.pam.Item", "Gifts.pam.count"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("HTML() names = %v; want %v", names, wantNames)
+	}
+
+	var got order
+	errs, err := form.Decode(postForm(values), &got)
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("Decode() = %v, %v; want no errors", errs, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %+v; want %+v", got, want)
+	}
+}
@@ -1,7 +1,10 @@
 package form
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -27,35 +30,100 @@ func valueOf(v interface{}) reflect.Value {
 	return rv
 }
 
-func fields(strct interface{}, parentNames ...string) []field {
+// fieldByIndex is like reflect.Value.FieldByIndex, but nil pointers to
+// embedded structs are treated as zero values rather than panicking.
+func fieldByIndex(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 {
+			rv = valueOf(rv)
+		}
+		rv = rv.Field(x)
+	}
+	return rv
+}
+
+// parseFields returns the fields for strct, which must be a struct or a
+// pointer to one. An error is returned if strct has an invalid struct tag.
+func parseFields(strct interface{}) ([]field, error) {
 	rv := valueOf(strct)
 	if rv.Kind() != reflect.Struct {
 		panic("form: invalid value; only structs are supported")
 	}
-	t := rv.Type()
+	p, err := planFor(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	return p.fields(rv, nil, 0), nil
+}
+
+// fields returns the fields for rv, a struct of the type p was built for.
+// scope is the number of parentNames up to and including the innermost slice
+// index or map key that rv is under, as used by inputName.
+func (p *structPlan) fields(rv reflect.Value, parentNames []string, scope int) []field {
 	var ret []field
-	for i := 0; i < t.NumField(); i++ {
-		tf := t.Field(i)
-		rvf := valueOf(rv.Field(i))
-		if !rvf.CanInterface() {
+	for i := range p.fieldPlans {
+		fp := &p.fieldPlans[i]
+		rvf := valueOf(fieldByIndex(rv, fp.index))
+		// Clip parentNames so that appending to it never overwrites the names
+		// used by a sibling.
+		names := append(parentNames[:len(parentNames):len(parentNames)], fp.name)
+		switch fp.kind {
+		case structKind:
+			ret = append(ret, fp.nested.fields(rvf, names, scope)...)
+		case structsKind, valuesKind:
+			ret = append(ret, fp.sliceFields(rvf, names, scope)...)
+		case mapKind:
+			ret = append(ret, fp.mapFields(rvf, names)...)
+		default:
+			ret = append(ret, fp.newInput(fp.inputName(names, scope), rvf))
+		}
+	}
+	return ret
+}
+
+// sliceFields returns the fields for a slice or array. Structs are given
+// indexed names like "Addresses.0.City", and name tags on their fields keep
+// the index, eg "Lines.0.count". Every element of a slice of any other type
+// shares a name like "Tags[]" so that they are submitted together.
+func (fp *fieldPlan) sliceFields(rv reflect.Value, names []string, scope int) []field {
+	var ret []field
+	for i := 0; i < rv.Len(); i++ {
+		elem := valueOf(rv.Index(i))
+		if fp.kind == structsKind {
+			ret = append(ret, fp.nested.fields(elem, append(names, strconv.Itoa(i)), len(names)+1)...)
 			continue
 		}
-		if rvf.Kind() == reflect.Struct {
-			nestedParentNames := append(parentNames, tf.Name)
-			nestedFields := fields(rvf.Interface(), nestedParentNames...)
-			ret = append(ret, nestedFields...)
+		f := fp.newInput(fp.inputName(names, scope), elem)
+		f.Name += "[]"
+		ret = append(ret, f)
+	}
+	return ret
+}
+
+// mapFields returns the fields for a map, sorted by key. Each entry is named
+// using its key, eg "Meta.color", and is labelled with its key unless a label
+// tag is provided. Name tags are ignored as every entry needs its own name.
+func (fp *fieldPlan) mapFields(rv reflect.Value, names []string) []field {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	var ret []field
+	for _, key := range keys {
+		k := fmt.Sprint(key)
+		entryNames := append(names, k)
+		elem := valueOf(rv.MapIndex(key))
+		if fp.nested != nil {
+			ret = append(ret, fp.nested.fields(elem, entryNames, len(entryNames))...)
 			continue
 		}
-		names := append(parentNames, tf.Name)
-		name := strings.Join(names, ".")
-		f := field{
-			Label:       tf.Name,
-			Name:        name,
-			Type:        "text",
-			Placeholder: tf.Name,
-			Value:       rvf.Interface(),
+		f := fp.newInput(strings.Join(entryNames, "."), elem)
+		if !fp.labelTag {
+			f.Label = k
+		}
+		if !fp.placeholderTag {
+			f.Placeholder = k
 		}
-		f.apply(parseTags(tf))
 		ret = append(ret, f)
 	}
 	return ret
@@ -68,9 +136,22 @@ type field struct {
 	Placeholder string
 	Value       interface{}
 	Errors      []string
+	Options     []Option
+
+	// rules are the validation rules from the field's struct tags, mapped to
+	// their values. See Validate for the supported rules.
+	rules map[string]string
 }
 
 func (f *field) apply(tags map[string]string) {
+	for _, rule := range ruleNames {
+		if v, ok := tags[rule]; ok {
+			if f.rules == nil {
+				f.rules = make(map[string]string)
+			}
+			f.rules[rule] = v
+		}
+	}
 	if v, ok := tags["name"]; ok {
 		f.Name = v
 	}
@@ -80,6 +161,10 @@ func (f *field) apply(tags map[string]string) {
 	if v, ok := tags["placeholder"]; ok {
 		f.Placeholder = v
 	}
+	if v, ok := tags["options"]; ok {
+		f.Options = parseOptions(v)
+		f.Type = "select"
+	}
 	if v, ok := tags["type"]; ok {
 		f.Type = v
 	}
@@ -93,22 +178,94 @@ func (f *field) setErrors(errors []FieldError) {
 	}
 }
 
-func parseTags(sf reflect.StructField) map[string]string {
+// parseTags parses the form struct tag of sf. Tags are separated by
+// semicolons and are either key=value pairs or flags such as "required":
+//
+//	label=Full Name;name=full_name;required
+//
+// Values may be wrapped in single quotes so that they can contain semicolons,
+// and a backslash escapes whatever character follows it:
+//
+//	placeholder='https://example.com/?a=b;c=d'
+//	placeholder=a\;b
+func parseTags(sf reflect.StructField) (map[string]string, error) {
 	// label=Full Name;name=full_name
 	rawTag := sf.Tag.Get("form")
 	if len(rawTag) == 0 {
-		return nil
+		return nil, nil
+	}
+	invalid := func(reason string) error {
+		return fmt.Errorf("form: invalid struct tag %q on field %s: %s", rawTag, sf.Name, reason)
 	}
 	ret := make(map[string]string)
-	// tags = [label=Full Name, name=full_name]
-	tags := strings.Split(rawTag, ";")
-	for _, tag := range tags {
-		kv := strings.Split(tag, "=")
-		if len(kv) != 2 {
-			panic("form: invalid struct tag")
+	var key, value strings.Builder
+	var inValue, quoted bool
+	add := func() error {
+		k := strings.TrimSpace(key.String())
+		switch {
+		case k == "" && !inValue:
+			// Empty tags, like the one after a trailing semicolon, are ignored.
+		case k == "":
+			return invalid("missing key")
+		case !inValue && !flagTags[k]:
+			return invalid(fmt.Sprintf("%s requires a value", k))
+		default:
+			ret[k] = value.String()
 		}
-		k, v := kv[0], kv[1]
-		ret[k] = v
+		key.Reset()
+		value.Reset()
+		inValue = false
+		return nil
 	}
-	return ret
+	for i := 0; i < len(rawTag); i++ {
+		c := rawTag[i]
+		switch {
+		case c == '\\':
+			i++
+			if i == len(rawTag) {
+				return nil, invalid("trailing backslash")
+			}
+			c = rawTag[i]
+		case quoted:
+			if c == '\'' {
+				quoted = false
+				continue
+			}
+		case c == '\'' && inValue && value.Len() == 0:
+			quoted = true
+			continue
+		case c == ';':
+			if err := add(); err != nil {
+				return nil, err
+			}
+			continue
+		case c == '=' && !inValue:
+			inValue = true
+			continue
+		}
+		if inValue {
+			value.WriteByte(c)
+		} else {
+			key.WriteByte(c)
+		}
+	}
+	if quoted {
+		return nil, invalid("unterminated quote")
+	}
+	if err := add(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// skipField reports whether sf has the `form:"-"` tag, which means it should
+// never be rendered or decoded.
+func skipField(sf reflect.StructField) bool {
+	return sf.Tag.Get("form") == "-"
+}
+
+// flagTags are the tags that are allowed to be used without a value.
+var flagTags = map[string]bool{
+	"required": true,
+	"email":    true,
 }
@@ -3,11 +3,32 @@ package form
 import (
 	"fmt"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 // TODO: Add test case for invalid struct tag value
 
+// fields is like parseFields, but it panics if strct has an invalid struct
+// tag so that tests of valid structs can use its result directly.
+func fields(strct interface{}) []field {
+	got, err := parseFields(strct)
+	if err != nil {
+		panic(err)
+	}
+	return got
+}
+
+type country string
+
+func (country) Options() []Option {
+	return []Option{
+		{Label: "United States", Value: "US"},
+		{Label: "Canada", Value: "CA"},
+	}
+}
+
 func TestParseTags(t *testing.T) {
 	tests := map[string]struct {
 		arg  reflect.StructField
@@ -34,10 +55,58 @@ func TestParseTags(t *testing.T) {
 				"name":  "full_name",
 			},
 		},
+		"flag tags": {
+			arg: reflect.StructField{
+				Tag: `form:"required;email;pattern=[a-z]+=[0-9]+"`,
+			},
+			want: map[string]string{
+				"required": "",
+				"email":    "",
+				"pattern":  "[a-z]+=[0-9]+",
+			},
+		},
+		"quoted values": {
+			arg: reflect.StructField{
+				Tag: `form:"placeholder='https://example.com/?a=b;c=d';label='Full Name'"`,
+			},
+			want: map[string]string{
+				"placeholder": "https://example.com/?a=b;c=d",
+				"label":       "Full Name",
+			},
+		},
+		"escaped values": {
+			arg: reflect.StructField{
+				Tag: `form:"placeholder=a\\=b\\;c;label='it\\'s'"`,
+			},
+			want: map[string]string{
+				"placeholder": "a=b;c",
+				"label":       "it's",
+			},
+		},
+		"apostrophes in unquoted values": {
+			arg: reflect.StructField{
+				Tag: `form:"label=Jon's name"`,
+			},
+			want: map[string]string{
+				"label": "Jon's name",
+			},
+		},
+		"trailing semicolons and spaces": {
+			arg: reflect.StructField{
+				Tag: `form:"label=Full Name; required;"`,
+			},
+			want: map[string]string{
+				"label":    "Full Name",
+				"required": "",
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := parseTags(tc.arg)
+			got, err := parseTags(tc.arg)
+			if err != nil {
+				t.Fatalf("parseTags() err = %v; want nil", err)
+			}
 			if len(got) != len(tc.want) {
 				t.Errorf("parseTags() len = %d, want %d", len(got), len(tc.want))
 			}
@@ -64,15 +133,94 @@ func TestParseTags_invalidTag(t *testing.T) {
 		arg reflect.StructField
 	}{
 		{reflect.StructField{Tag: `form:"invalid-value"`}},
+		{reflect.StructField{Tag: `form:"=value"`}},
+		{reflect.StructField{Tag: `form:"placeholder='unterminated"`}},
+		{reflect.StructField{Tag: `form:"placeholder=trailing\\"`}},
 	}
 	for _, tc := range tests {
 		t.Run(string(tc.arg.Tag), func(t *testing.T) {
-			defer func() {
-				if err := recover(); err == nil {
-					t.Errorf("parseTags() did not panic")
+			_, err := parseTags(tc.arg)
+			if err == nil {
+				t.Errorf("parseTags() err = nil; want an error")
+			}
+		})
+	}
+}
+
+func TestPlanFor(t *testing.T) {
+	type node struct {
+		Name     string
+		Children []node
+	}
+	tests := map[string]struct {
+		strct   interface{}
+		wantErr bool
+	}{
+		"valid tags": {
+			strct: struct {
+				Name string `form:"label=Full Name;required;min=2"`
+			}{},
+		},
+		"recursive types": {
+			strct: node{},
+		},
+		"skipped and unexported fields are not checked": {
+			strct: struct {
+				Skipped string `form:"-"`
+				private string `form:"invalid"`
+			}{},
+		},
+		"invalid tags in nested structs": {
+			strct: struct {
+				Address struct {
+					Street string `form:"invalid"`
 				}
-			}()
-			parseTags(tc.arg)
+			}{},
+			wantErr: true,
+		},
+		"invalid tags in slices of structs": {
+			strct: struct {
+				Addresses []*struct {
+					Street string `form:"invalid"`
+				}
+			}{},
+			wantErr: true,
+		},
+		"non-numeric min rule": {
+			strct: struct {
+				Name string `form:"min=three"`
+			}{},
+			wantErr: true,
+		},
+		"max rule on a bool": {
+			strct: struct {
+				Gift bool `form:"max=1"`
+			}{},
+			wantErr: true,
+		},
+		"invalid pattern rule": {
+			strct: struct {
+				Name string `form:"pattern=[a-z"`
+			}{},
+			wantErr: true,
+		},
+		"recursive embedded structs": {
+			strct: embeddedNode{},
+		},
+		"invalid tags in embedded structs": {
+			strct: struct {
+				Base
+				invalidEmbed
+			}{},
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := planFor(reflect.TypeOf(tc.strct))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("planFor() err = %v; want error = %t", err, tc.wantErr)
+			}
 		})
 	}
 }
@@ -405,6 +553,193 @@ func TestFields(t *testing.T) {
 				},
 			},
 		},
+		"Slices of structs should use indexed names": {
+			strct: struct {
+				Addresses []struct {
+					Street string
+				}
+			}{
+				Addresses: []struct {
+					Street string
+				}{
+					{Street: "123 Fake St"},
+					{Street: "1725 Slough Ave"},
+				},
+			},
+			want: []field{
+				{
+					Label:       "Street",
+					Name:        "Addresses.0.Street",
+					Type:        "text",
+					Placeholder: "Street",
+					Value:       "123 Fake St",
+				},
+				{
+					Label:       "Street",
+					Name:        "Addresses.1.Street",
+					Type:        "text",
+					Placeholder: "Street",
+					Value:       "1725 Slough Ave",
+				},
+			},
+		},
+		"Slices and arrays of other types should share a name": {
+			strct: struct {
+				Tags   []string `form:"name=tags"`
+				Scores [2]int
+			}{
+				Tags:   []string{"go", "testing"},
+				Scores: [2]int{10, 20},
+			},
+			want: []field{
+				{
+					Label:       "Tags",
+					Name:        "tags[]",
+					Type:        "text",
+					Placeholder: "Tags",
+					Value:       "go",
+				},
+				{
+					Label:       "Tags",
+					Name:        "tags[]",
+					Type:        "text",
+					Placeholder: "Tags",
+					Value:       "testing",
+				},
+				{
+					Label:       "Scores",
+					Name:        "Scores[]",
+					Type:        "text",
+					Placeholder: "Scores",
+					Value:       10,
+				},
+				{
+					Label:       "Scores",
+					Name:        "Scores[]",
+					Type:        "text",
+					Placeholder: "Scores",
+					Value:       20,
+				},
+			},
+		},
+		"Maps should be sorted by key": {
+			strct: struct {
+				Meta map[string]string
+				Pets map[string]struct {
+					Name string
+				}
+			}{
+				Meta: map[string]string{"size": "L", "color": "blue"},
+				Pets: map[string]struct {
+					Name string
+				}{
+					"dog": {Name: "Sprinkles"},
+				},
+			},
+			want: []field{
+				{
+					Label:       "color",
+					Name:        "Meta.color",
+					Type:        "text",
+					Placeholder: "color",
+					Value:       "blue",
+				},
+				{
+					Label:       "size",
+					Name:        "Meta.size",
+					Type:        "text",
+					Placeholder: "size",
+					Value:       "L",
+				},
+				{
+					Label:       "Name",
+					Name:        "Pets.dog.Name",
+					Type:        "text",
+					Placeholder: "Name",
+					Value:       "Sprinkles",
+				},
+			},
+		},
+		"Bools should be checkboxes": {
+			strct: struct {
+				Gift bool
+			}{
+				Gift: true,
+			},
+			want: []field{
+				{
+					Label:       "Gift",
+					Name:        "Gift",
+					Type:        "checkbox",
+					Placeholder: "Gift",
+					Value:       true,
+				},
+			},
+		},
+		"Options should be supported": {
+			strct: struct {
+				Size    string `form:"options=S,M,L"`
+				Color   string `form:"type=radio;options=red,blue"`
+				Country country
+			}{
+				Size:    "M",
+				Country: "CA",
+			},
+			want: []field{
+				{
+					Label:       "Size",
+					Name:        "Size",
+					Type:        "select",
+					Placeholder: "Size",
+					Value:       "M",
+					Options: []Option{
+						{Label: "S", Value: "S"},
+						{Label: "M", Value: "M", Selected: true},
+						{Label: "L", Value: "L"},
+					},
+				},
+				{
+					Label:       "Color",
+					Name:        "Color",
+					Type:        "radio",
+					Placeholder: "Color",
+					Value:       "",
+					Options: []Option{
+						{Label: "red", Value: "red"},
+						{Label: "blue", Value: "blue"},
+					},
+				},
+				{
+					Label:       "Country",
+					Name:        "Country",
+					Type:        "select",
+					Placeholder: "Country",
+					Value:       country("CA"),
+					Options: []Option{
+						{Label: "United States", Value: "US"},
+						{Label: "Canada", Value: "CA", Selected: true},
+					},
+				},
+			},
+		},
+		"Fields tagged with - should be skipped": {
+			strct: struct {
+				Name     string
+				Password string `form:"-"`
+				Address  struct {
+					Street string
+				} `form:"-"`
+			}{},
+			want: []field{
+				{
+					Label:       "Name",
+					Name:        "Name",
+					Type:        "text",
+					Placeholder: "Name",
+					Value:       "",
+				},
+			},
+		},
 		"Struct tags": {
 			strct: struct {
 				LabelTest       string `form:"label=This is custom"`
@@ -489,6 +824,9 @@ func TestFields(t *testing.T) {
 				if gotField.Value != wantField.Value {
 					t.Errorf("  .Value = %v; want %v", gotField.Value, wantField.Value)
 				}
+				if !reflect.DeepEqual(gotField.Options, wantField.Options) {
+					t.Errorf("  .Options = %v; want %v", gotField.Options, wantField.Options)
+				}
 			}
 		})
 	}
@@ -514,6 +852,211 @@ func TestFields_invalidTypes(t *testing.T) {
 	}
 }
 
+func TestFields_concurrent(t *testing.T) {
+	type address struct {
+		Street string
+		Zip    int
+	}
+	type order struct {
+		Name      string `form:"required"`
+		Addresses []address
+	}
+	strct := order{
+		Name:      "Michael Scott",
+		Addresses: []address{{Street: "1725 Slough Ave", Zip: 18505}},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := fields(strct); len(got) != 3 {
+				t.Errorf("fields() len = %d; want 3", len(got))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+type Base struct {
+	ID      int
+	Created time.Time `form:"type=date"`
+}
+
+type audit struct {
+	UpdatedBy string
+}
+
+type embeddedNode struct {
+	*embeddedNode
+	Name string
+}
+
+type invalidEmbed struct {
+	Note string `form:"invalid"`
+}
+
+// rgb is rendered as a hex colour rather than as a struct.
+type rgb struct {
+	R, G, B uint8
+}
+
+func (c rgb) FormValue() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func TestFields_embedded(t *testing.T) {
+	created := time.Date(2018, 11, 5, 9, 30, 0, 0, time.UTC)
+	tests := map[string]struct {
+		strct interface{}
+		want  []string
+	}{
+		"Embedded fields are promoted": {
+			strct: struct {
+				Base
+				Name string
+			}{Base: Base{ID: 7, Created: created}},
+			want: []string{"ID=7", "Created=2018-11-05", "Name="},
+		},
+		"Embedded pointers are promoted even when nil": {
+			strct: struct {
+				*Base
+				Name string
+			}{},
+			want: []string{"ID=0", "Created=", "Name="},
+		},
+		"Unexported embedded structs are promoted": {
+			strct: struct {
+				audit
+			}{audit{UpdatedBy: "Dwight"}},
+			want: []string{"UpdatedBy=Dwight"},
+		},
+		"Outer fields hide promoted ones": {
+			strct: struct {
+				Base
+				ID string
+			}{Base: Base{ID: 7}, ID: "outer"},
+			want: []string{"Created=", "ID=outer"},
+		},
+		"Nested fields do not hide promoted ones": {
+			strct: struct {
+				Base
+				Other struct{ ID int }
+			}{},
+			want: []string{"ID=0", "Created=", "Other.ID=0"},
+		},
+		"Embedded structs with a name tag are nested": {
+			strct: struct {
+				Base `form:"name=base"`
+			}{},
+			want: []string{"Base.ID=0", "Base.Created="},
+		},
+		"Recursive embedding stops": {
+			strct: embeddedNode{Name: "leaf"},
+			want:  []string{"Name=leaf"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, f := range fields(tc.strct) {
+				got = append(got, fmt.Sprintf("%s=%v", f.Name, f.Value))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("fields() = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFields_ambiguousEmbedded(t *testing.T) {
+	type a struct{ Name string }
+	type b struct{ Name string }
+	got := fields(struct {
+		a
+		b
+		Email string
+	}{})
+	if len(got) != 1 || got[0].Name != "Email" {
+		t.Errorf("fields() = %v; want only Email", got)
+	}
+}
+
+func TestFields_customValues(t *testing.T) {
+	ships := time.Date(2018, 11, 5, 9, 30, 0, 0, time.UTC)
+	got := fields(struct {
+		Ships    time.Time
+		Birthday time.Time `form:"type=date"`
+		Expires  *time.Time
+		Colour   rgb
+		Colours  []rgb
+	}{
+		Ships:    ships,
+		Birthday: ships,
+		Colour:   rgb{R: 255, G: 128},
+		Colours:  []rgb{{B: 1}},
+	})
+	want := []field{
+		{Label: "Ships", Name: "Ships", Type: "datetime-local", Placeholder: "Ships", Value: "2018-11-05T09:30"},
+		{Label: "Birthday", Name: "Birthday", Type: "date", Placeholder: "Birthday", Value: "2018-11-05"},
+		{Label: "Expires", Name: "Expires", Type: "datetime-local", Placeholder: "Expires", Value: ""},
+		{Label: "Colour", Name: "Colour", Type: "text", Placeholder: "Colour", Value: "#ff8000"},
+		{Label: "Colours", Name: "Colours[]", Type: "text", Placeholder: "Colours", Value: "#000001"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields() = %+v; want %+v", got, want)
+	}
+}
+
+type benchOrder struct {
+	Name    string `form:"label=Full Name;placeholder=Michael Scott;required"`
+	Email   string `form:"type=email;required;email"`
+	Country string `form:"options=US,CA,MX"`
+	Gift    bool
+	Notes   string `form:"type=textarea;max=500"`
+	Address struct {
+		Street string `form:"required"`
+		City   string `form:"required"`
+		Zip    string `form:"pattern=[0-9]{5}"`
+	}
+	Lines []struct {
+		Item     string `form:"oneof=shirt,mug,sticker"`
+		Quantity int    `form:"min=1;max=10"`
+	}
+}
+
+func newBenchOrder() benchOrder {
+	var o benchOrder
+	o.Name = "Michael Scott"
+	o.Email = "michael@dundermifflin.com"
+	o.Country = "US"
+	o.Address.Street = "1725 Slough Ave"
+	o.Address.City = "Scranton"
+	o.Address.Zip = "18505"
+	o.Lines = make([]struct {
+		Item     string `form:"oneof=shirt,mug,sticker"`
+		Quantity int    `form:"min=1;max=10"`
+	}, 3)
+	return o
+}
+
+func BenchmarkFields(b *testing.B) {
+	strct := newBenchOrder()
+	for i := 0; i < b.N; i++ {
+		fields(strct)
+	}
+}
+
+// BenchmarkFields_uncached clears the cached plan for the struct's type on
+// every iteration to show what rendering costs without the cache.
+func BenchmarkFields_uncached(b *testing.B) {
+	strct := newBenchOrder()
+	for i := 0; i < b.N; i++ {
+		plans = sync.Map{}
+		fields(strct)
+	}
+}
+
 // func TestFields_labels(t *testing.T) {
 // 	hasLabels := func(labels ...string) func(*testing.T, []field) {
 // 		return func(t *testing.T, fields []field) {
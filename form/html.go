@@ -33,9 +33,28 @@ type FieldError struct {
 //
 // An example similar to this is shown as the first test case in TestHTML
 // in the html_test.go source file.
+//
+// Bool fields are given the "checkbox" type, and fields with an options
+// struct tag (eg `form:"options=US,CA,MX"`) or a type implementing Optioner
+// are given the "select" type. Templates can range over .Options to render
+// the choices for these, and a type tag such as "type=radio" can be used to
+// pick a different control.
+//
+// time.Time fields are given the "datetime-local" type, or can be given the
+// "date" type with a tag, and their values are formatted to suit. Types that
+// implement FormValuer provide their own value instead. The fields of
+// embedded structs are promoted just as they are in Go, so they are named
+// "ID" rather than "Base.ID".
+//
+// Fields with the `form:"-"` struct tag are skipped. If strct has an invalid
+// struct tag an error is returned describing it.
 func HTML(t *template.Template, strct interface{}, errors ...FieldError) (template.HTML, error) {
+	fields, err := parseFields(strct)
+	if err != nil {
+		return "", err
+	}
 	var inputs []string
-	for _, field := range fields(strct) {
+	for _, field := range fields {
 		field.setErrors(errors)
 		var sb strings.Builder
 		err := t.Execute(&sb, field)
@@ -38,6 +38,25 @@ var (
 	{{range .Errors}}
 		<p class="text-red text-xs italic">{{.}}</p>
 	{{end}}`))
+	tplControls = template.Must(template.New("").Parse(`
+	<label>{{.Label}}</label>
+	{{- if eq .Type "select"}}
+	<select name="{{.Name}}">
+		{{- range .Options}}
+		<option value="{{.Value}}"{{if .Selected}} selected{{end}}>{{.Label}}</option>
+		{{- end}}
+	</select>
+	{{- else if eq .Type "radio"}}
+		{{- range .Options}}
+	<input type="radio" name="{{$.Name}}" value="{{.Value}}"{{if .Selected}} checked{{end}}> {{.Label}}
+		{{- end}}
+	{{- else if eq .Type "checkbox"}}
+	<input type="checkbox" name="{{.Name}}"{{if .Value}} checked{{end}}>
+	{{- else if eq .Type "textarea"}}
+	<textarea name="{{.Name}}" placeholder="{{.Placeholder}}">{{.Value}}</textarea>
+	{{- else}}
+	<input type="{{.Type}}" name="{{.Name}}" placeholder="{{.Placeholder}}"{{with .Value}} value="{{.}}"{{end}}>
+	{{- end}}`))
 )
 
 func TestHTML(t *testing.T) {
@@ -92,6 +111,22 @@ func TestHTML(t *testing.T) {
 			},
 			want: "TestHTML_errors.golden",
 		},
+		"A form with selects, radios, checkboxes and textareas": {
+			tpl: tplControls,
+			strct: struct {
+				Country string `form:"options=US,CA,MX"`
+				Size    string `form:"type=radio;options=S,M,L"`
+				Gift    bool   `form:"label=This is a gift"`
+				Notes   string `form:"type=textarea;placeholder=Delivery instructions"`
+				Email   string `form:"type=email"`
+			}{
+				Country: "CA",
+				Size:    "L",
+				Gift:    true,
+				Notes:   "Leave it with Dwight",
+			},
+			want: "TestHTML_controls.golden",
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -115,6 +150,16 @@ func TestHTML(t *testing.T) {
 	}
 }
 
+func TestHTML_invalidTags(t *testing.T) {
+	strct := struct {
+		Name string `form:"label=Full Name;placeholder='unterminated"`
+	}{}
+	_, err := form.HTML(tplTypeNameValue, strct)
+	if err == nil {
+		t.Errorf("HTML() err = nil; want an error")
+	}
+}
+
 func writeFile(t *testing.T, filename, contents string) {
 	f, err := os.Create(filename)
 	if err != nil {
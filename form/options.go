@@ -0,0 +1,66 @@
+package form
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Option is a single choice for a select or radio input. Selected is set by
+// HTML when the option's Value matches the field's value so that templates
+// can mark it as selected or checked.
+type Option struct {
+	Label    string
+	Value    string
+	Selected bool
+}
+
+// Optioner is implemented by types that only have a few valid values, such
+// as a Country type. Fields with a type that implements Optioner default to
+// being rendered as a select using the returned options. An options struct
+// tag takes precedence over the Options method.
+type Optioner interface {
+	Options() []Option
+}
+
+// optionsFor returns the options provided by rv if it implements Optioner.
+func optionsFor(rv reflect.Value) []Option {
+	if rv.CanAddr() {
+		if o, ok := rv.Addr().Interface().(Optioner); ok {
+			return o.Options()
+		}
+	}
+	if !rv.CanInterface() {
+		return nil
+	}
+	if o, ok := rv.Interface().(Optioner); ok {
+		return o.Options()
+	}
+	return nil
+}
+
+// parseOptions parses the value of an options struct tag, eg "US,CA,MX".
+// Each option is used for both the label and value.
+func parseOptions(v string) []Option {
+	var ret []Option
+	for _, o := range strings.Split(v, ",") {
+		ret = append(ret, Option{Label: o, Value: o})
+	}
+	return ret
+}
+
+// selectOptions marks the options whose value matches the field's value as
+// selected. The options are copied first so that options shared between
+// fields, such as those returned by an Optioner, are never modified.
+func (f *field) selectOptions() {
+	if len(f.Options) == 0 {
+		return
+	}
+	value := fmt.Sprint(f.Value)
+	opts := make([]Option, len(f.Options))
+	for i, o := range f.Options {
+		o.Selected = o.Value == value
+		opts[i] = o
+	}
+	f.Options = opts
+}
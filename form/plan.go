@@ -0,0 +1,301 @@
+package form
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structPlan describes how to render and decode a struct type. Building a
+// plan means reflecting over every field of the type and parsing its struct
+// tags, so plans are built once per type and cached. Rendering a struct then
+// only needs to read its values.
+type structPlan struct {
+	fieldPlans []fieldPlan
+	// err is the first invalid struct tag or rule found in the type,
+	// including those in any nested types.
+	err error
+}
+
+type fieldKind int
+
+const (
+	valueKind   fieldKind = iota // a single input, such as a string or int
+	structKind                   // a nested struct, or a pointer to one
+	structsKind                  // a slice or array of structs
+	valuesKind                   // a slice or array of anything else
+	mapKind                      // a map of structs or of anything else
+)
+
+type fieldPlan struct {
+	// index is the index sequence of the field, as used by FieldByIndex.
+	// Fields promoted from embedded structs have more than one index.
+	index []int
+	name  string
+	kind  fieldKind
+
+	// typ is the type of the values rendered as inputs. For slices, arrays and
+	// maps this is the element type, and pointers are always dereferenced.
+	typ reflect.Type
+
+	// nested is the plan for nested structs, including the elements of
+	// slices, arrays and maps of structs.
+	nested *structPlan
+
+	// input is used as the starting point for every input rendered for this
+	// field, with only its Name, Value and Options left to fill in. Its Name
+	// is only set when the field has a name tag.
+	input field
+
+	// optioner is true when the field's values implement Optioner, so their
+	// options need to be read each time they are rendered.
+	optioner bool
+
+	// labelTag and placeholderTag record whether those tags were used, as map
+	// entries default to using their key instead of the field's name.
+	labelTag, placeholderTag bool
+}
+
+var (
+	plans sync.Map // map[reflect.Type]*structPlan
+
+	optionerType        = reflect.TypeOf((*Optioner)(nil)).Elem()
+	formValuerType      = reflect.TypeOf((*FormValuer)(nil)).Elem()
+	formUnmarshalerType = reflect.TypeOf((*FormUnmarshaler)(nil)).Elem()
+)
+
+// planFor returns the plan for the struct type t, building and caching it
+// if this is the first time t has been seen. It is safe to call from
+// multiple goroutines.
+func planFor(t reflect.Type) (*structPlan, error) {
+	if p, ok := plans.Load(t); ok {
+		p := p.(*structPlan)
+		return p, p.err
+	}
+	building := make(map[reflect.Type]*structPlan)
+	p := buildPlan(t, building)
+	if p.err != nil {
+		// Nested plans might be incomplete if we stopped early, so only the
+		// error is cached.
+		plans.Store(t, p)
+		return p, p.err
+	}
+	for bt, bp := range building {
+		plans.LoadOrStore(bt, bp)
+	}
+	return p, nil
+}
+
+// buildPlan builds the plan for the struct type t. Plans that are still
+// being built are tracked in building so that recursive types refer back to
+// the same plan instead of being built forever. Errors in nested plans are
+// copied up to their parent.
+func buildPlan(t reflect.Type, building map[reflect.Type]*structPlan) *structPlan {
+	if p, ok := plans.Load(t); ok {
+		return p.(*structPlan)
+	}
+	if p, ok := building[t]; ok {
+		return p
+	}
+	p := &structPlan{}
+	building[t] = p
+	fps, err := buildFieldPlans(t, nil, building, map[reflect.Type]bool{t: true})
+	if err != nil {
+		p.err = err
+		return p
+	}
+	p.fieldPlans = dominantFields(fps)
+	return p
+}
+
+// buildFieldPlans returns the plans for the fields of the struct type t,
+// including any promoted from embedded structs. index is the index sequence
+// of t within the struct the plan is for, and embedded holds the embedded
+// types being promoted so that a type embedding itself doesn't recurse
+// forever.
+func buildFieldPlans(t reflect.Type, index []int, building map[reflect.Type]*structPlan, embedded map[reflect.Type]bool) ([]fieldPlan, error) {
+	var fps []fieldPlan
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		if skipField(tf) {
+			continue
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+		if et, ok := embeddedStruct(tf); ok {
+			if embedded[et] {
+				continue
+			}
+			embedded[et] = true
+			promoted, err := buildFieldPlans(et, fieldIndex, building, embedded)
+			delete(embedded, et)
+			if err != nil {
+				return nil, err
+			}
+			fps = append(fps, promoted...)
+			continue
+		}
+		if tf.PkgPath != "" {
+			continue
+		}
+		fp, err := buildFieldPlan(tf, building)
+		if err != nil {
+			return nil, err
+		}
+		fp.index = fieldIndex
+		fps = append(fps, fp)
+	}
+	return fps, nil
+}
+
+// embeddedStruct returns the struct type embedded by sf if its fields should
+// be promoted, which is the case for embedded structs and pointers to them
+// unless they have a name tag. Embedded structs with a name tag are treated
+// like any other nested struct. Fields of unexported embedded structs are
+// still promoted, but not those of unexported pointers as Decode would have
+// no way to allocate them.
+func embeddedStruct(sf reflect.StructField) (reflect.Type, bool) {
+	if !sf.Anonymous {
+		return nil, false
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		if sf.PkgPath != "" {
+			return nil, false
+		}
+		t = t.Elem()
+	}
+	if !isStruct(t) {
+		return nil, false
+	}
+	// Invalid tags are left for buildFieldPlan to report.
+	tags, err := parseTags(sf)
+	if err != nil {
+		return nil, false
+	}
+	if _, ok := tags["name"]; ok {
+		return nil, false
+	}
+	return t, true
+}
+
+// dominantFields applies Go's rules for promoted fields to fps. A field is
+// hidden by any field with the same name that is embedded less deeply, and
+// fields with the same name at the same depth hide each other.
+func dominantFields(fps []fieldPlan) []fieldPlan {
+	depths := make(map[string]int)
+	counts := make(map[string]int)
+	for _, fp := range fps {
+		depth, ok := depths[fp.name]
+		switch {
+		case !ok || len(fp.index) < depth:
+			depths[fp.name] = len(fp.index)
+			counts[fp.name] = 1
+		case len(fp.index) == depth:
+			counts[fp.name]++
+		}
+	}
+	var ret []fieldPlan
+	for _, fp := range fps {
+		if len(fp.index) == depths[fp.name] && counts[fp.name] == 1 {
+			ret = append(ret, fp)
+		}
+	}
+	return ret
+}
+
+func buildFieldPlan(tf reflect.StructField, building map[reflect.Type]*structPlan) (fieldPlan, error) {
+	tags, err := parseTags(tf)
+	if err != nil {
+		return fieldPlan{}, err
+	}
+	if err := checkRules(tf, tags); err != nil {
+		return fieldPlan{}, err
+	}
+	fp := fieldPlan{name: tf.Name}
+	t := indirectType(tf.Type)
+	switch {
+	case isCustom(t):
+		// Types that render or decode themselves are always a single input,
+		// whatever their kind.
+	case t.Kind() == reflect.Slice, t.Kind() == reflect.Array:
+		fp.kind = valuesKind
+		t = indirectType(t.Elem())
+		if isStruct(t) {
+			fp.kind = structsKind
+		}
+	case t.Kind() == reflect.Map:
+		fp.kind = mapKind
+		t = indirectType(t.Elem())
+	case isStruct(t):
+		fp.kind = structKind
+	}
+	fp.typ = t
+	if isStruct(t) {
+		fp.nested = buildPlan(t, building)
+		return fp, fp.nested.err
+	}
+	fp.optioner = t.Implements(optionerType) || reflect.PtrTo(t).Implements(optionerType)
+	fp.input = field{
+		Label:       tf.Name,
+		Type:        "text",
+		Placeholder: tf.Name,
+	}
+	switch {
+	case fp.optioner:
+		fp.input.Type = "select"
+	case t == timeType:
+		fp.input.Type = "datetime-local"
+	case t.Kind() == reflect.Bool:
+		fp.input.Type = "checkbox"
+	}
+	fp.input.apply(tags)
+	_, fp.labelTag = tags["label"]
+	_, fp.placeholderTag = tags["placeholder"]
+	return fp, nil
+}
+
+// inputName returns the name of fp's input, where names are the names of
+// the field and its parents. A name tag replaces those names, except for the
+// first scope of them, which lead up to the innermost slice index or map key
+// the field is under. That way every element still gets its own name, eg
+// "Lines.0.count" rather than just "count".
+func (fp *fieldPlan) inputName(names []string, scope int) string {
+	if fp.input.Name == "" {
+		return strings.Join(names, ".")
+	}
+	return strings.Join(append(names[:scope:scope], fp.input.Name), ".")
+}
+
+// newInput returns the field named name used to render rv, which is either
+// the value of this field or one of its elements.
+func (fp *fieldPlan) newInput(name string, rv reflect.Value) field {
+	f := fp.input
+	f.Name = name
+	f.Value = inputValue(rv, f.Type)
+	if f.Options == nil && fp.optioner {
+		f.Options = optionsFor(rv)
+	}
+	f.selectOptions()
+	return f
+}
+
+// isStruct reports whether t is a struct whose fields are rendered as inputs,
+// rather than a struct such as time.Time that is rendered as a single input.
+func isStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !isCustom(t)
+}
+
+// isCustom reports whether t, or a pointer to t, implements FormValuer or
+// FormUnmarshaler.
+func isCustom(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return t.Implements(formValuerType) || pt.Implements(formValuerType) ||
+		t.Implements(formUnmarshalerType) || pt.Implements(formUnmarshalerType)
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
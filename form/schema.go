@@ -0,0 +1,234 @@
+package form
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the JSON Schema dialect Schema generates documents for.
+const SchemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema returns a JSON Schema document describing the values submitted by
+// a form that HTML generates from strct, so that the same Go struct can
+// drive validation in the browser.
+//
+// The document describes the flat set of names a form submits rather than
+// the shape of the Go struct, so every input is a property named just like
+// its HTML input, eg "Address.City". Inputs whose names include a slice
+// index or map key, such as "Addresses.0.City", are described with
+// patternProperties instead. Each property has a title taken from its label,
+// an example taken from its placeholder tag, and keywords matching any of
+// its validation rules.
+func Schema(strct interface{}) ([]byte, error) {
+	rv := valueOf(strct)
+	if rv.Kind() != reflect.Struct {
+		panic("form: invalid value; only structs are supported")
+	}
+	p, err := planFor(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	doc := schemaDocument{
+		Schema:            SchemaVersion,
+		Title:             rv.Type().Name(),
+		Type:              "object",
+		Properties:        make(map[string]*schemaProperty),
+		PatternProperties: make(map[string]*schemaProperty),
+	}
+	p.schema(&doc, nil, nil, 0, make(map[*structPlan]bool))
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type schemaDocument struct {
+	Schema            string                     `json:"$schema"`
+	Title             string                     `json:"title,omitempty"`
+	Type              string                     `json:"type"`
+	Properties        map[string]*schemaProperty `json:"properties,omitempty"`
+	PatternProperties map[string]*schemaProperty `json:"patternProperties,omitempty"`
+	Required          []string                   `json:"required,omitempty"`
+}
+
+type schemaProperty struct {
+	Type      string          `json:"type,omitempty"`
+	Format    string          `json:"format,omitempty"`
+	Title     string          `json:"title,omitempty"`
+	Examples  []string        `json:"examples,omitempty"`
+	Enum      []interface{}   `json:"enum,omitempty"`
+	Pattern   string          `json:"pattern,omitempty"`
+	MinLength json.Number     `json:"minLength,omitempty"`
+	MaxLength json.Number     `json:"maxLength,omitempty"`
+	Minimum   json.Number     `json:"minimum,omitempty"`
+	Maximum   json.Number     `json:"maximum,omitempty"`
+	MinItems  int             `json:"minItems,omitempty"`
+	Items     *schemaProperty `json:"items,omitempty"`
+}
+
+// schema adds the properties for p to doc. names holds the parts of the
+// names used so far, and patterns holds the same parts as regular
+// expressions. A part that is a slice index or map key only appears in
+// patterns, which means any properties from then on are pattern properties.
+// scope is the number of patterns up to and including the innermost of
+// those parts, which name tags don't replace; see inputName.
+//
+// expanding holds the plans whose properties are currently being added. The
+// fields of a recursive type, such as a node with a slice of child nodes,
+// could be submitted with names of any depth, so a plan is not expanded
+// again inside itself and those deeper inputs are left undescribed.
+func (p *structPlan) schema(doc *schemaDocument, names, patterns []string, scope int, expanding map[*structPlan]bool) {
+	expanding[p] = true
+	defer delete(expanding, p)
+	dynamic := len(patterns) > len(names)
+	for i := range p.fieldPlans {
+		fp := &p.fieldPlans[i]
+		if fp.nested != nil && expanding[fp.nested] {
+			continue
+		}
+		fieldNames := append(names[:len(names):len(names)], fp.name)
+		fieldPatterns := append(patterns[:len(patterns):len(patterns)], regexp.QuoteMeta(fp.name))
+		switch fp.kind {
+		case structKind:
+			fp.nested.schema(doc, fieldNames, fieldPatterns, scope, expanding)
+		case structsKind:
+			fp.nested.schema(doc, fieldNames, append(fieldPatterns, "[0-9]+"), len(fieldPatterns)+1, expanding)
+		case mapKind:
+			if fp.nested != nil {
+				fp.nested.schema(doc, fieldNames, append(fieldPatterns, "[^.]+"), len(fieldPatterns)+1, expanding)
+				continue
+			}
+			pattern := "^" + strings.Join(append(fieldPatterns, ".+"), `\.`) + "$"
+			doc.PatternProperties[pattern] = fp.schemaProperty(fp.labelTag)
+		default:
+			prop := fp.schemaProperty(true)
+			if fp.kind == valuesKind {
+				prop = &schemaProperty{
+					Type:  "array",
+					Title: prop.Title,
+					Items: prop,
+				}
+				prop.Items.Title = ""
+				if _, ok := fp.input.rules["required"]; ok {
+					prop.MinItems = 1
+				}
+			}
+			if !dynamic {
+				name := fp.inputName(fieldNames, 0)
+				if fp.kind == valuesKind {
+					name += "[]"
+				}
+				doc.Properties[name] = prop
+				if _, ok := fp.input.rules["required"]; ok {
+					doc.Required = append(doc.Required, name)
+				}
+				continue
+			}
+			if fp.input.Name != "" {
+				fieldPatterns = append(patterns[:scope:scope], regexp.QuoteMeta(fp.input.Name))
+			}
+			pattern := "^" + strings.Join(fieldPatterns, `\.`)
+			if fp.kind == valuesKind {
+				pattern += `\[\]`
+			}
+			doc.PatternProperties[pattern+"$"] = prop
+		}
+	}
+}
+
+// schemaProperty returns the schema for a single input of fp. Map entries
+// are labelled with their keys unless they have a label tag, so the title
+// is only included when withTitle is true.
+func (fp *fieldPlan) schemaProperty(withTitle bool) *schemaProperty {
+	prop := &schemaProperty{}
+	if withTitle {
+		prop.Title = fp.input.Label
+	}
+	// Placeholders default to the field's name, which makes for a poor
+	// example, so only those set with a tag are used.
+	if fp.placeholderTag {
+		prop.Examples = []string{fp.input.Placeholder}
+	}
+	switch {
+	case fp.typ == timeType:
+		prop.Type = "string"
+		if fp.input.Type == "date" {
+			prop.Format = "date"
+		}
+	case isCustom(fp.typ):
+		prop.Type = "string"
+	case fp.typ.Kind() == reflect.String:
+		prop.Type = "string"
+	case fp.typ.Kind() == reflect.Bool:
+		prop.Type = "boolean"
+	case fp.typ.Kind() >= reflect.Int && fp.typ.Kind() <= reflect.Uint64:
+		prop.Type = "integer"
+	case fp.typ.Kind() == reflect.Float32 || fp.typ.Kind() == reflect.Float64:
+		prop.Type = "number"
+	}
+	if fp.input.Type == "email" {
+		prop.Format = "email"
+	}
+
+	rules := fp.input.rules
+	if _, ok := rules["email"]; ok {
+		prop.Format = "email"
+	}
+	if v, ok := rules["pattern"]; ok {
+		prop.Pattern = anchor(v)
+	}
+	if v, ok := rules["min"]; ok {
+		if prop.Type == "string" {
+			prop.MinLength = json.Number(v)
+		} else {
+			prop.Minimum = json.Number(v)
+		}
+	}
+	if v, ok := rules["max"]; ok {
+		if prop.Type == "string" {
+			prop.MaxLength = json.Number(v)
+		} else {
+			prop.Maximum = json.Number(v)
+		}
+	}
+
+	// Forms submit empty inputs as empty strings, so required strings need
+	// a minimum length for the schema to reject them as Validate does.
+	if _, ok := rules["required"]; ok && prop.Type == "string" {
+		if n, err := strconv.ParseFloat(string(prop.MinLength), 64); err != nil || n < 1 {
+			prop.MinLength = "1"
+		}
+	}
+
+	options := fp.input.Options
+	if options == nil && fp.optioner {
+		options = optionsFor(reflect.New(fp.typ).Elem())
+	}
+	var values []string
+	for _, o := range options {
+		values = append(values, o.Value)
+	}
+	if v, ok := rules["oneof"]; ok {
+		values = strings.Split(v, ",")
+	}
+	for _, v := range values {
+		prop.Enum = append(prop.Enum, enumValue(prop.Type, v))
+	}
+	return prop
+}
+
+// enumValue converts v into a value with the JSON type typ, so that the
+// enum for an integer property holds numbers rather than strings.
+func enumValue(typ, v string) interface{} {
+	switch typ {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
@@ -0,0 +1,128 @@
+package form_test
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joncalhoun/twg/form"
+)
+
+type swagOrder struct {
+	Timestamps
+	Name     string  `form:"label=Full Name;placeholder=Michael Scott;required;min=2;max=50"`
+	Email    string  `form:"type=email;required"`
+	Country  string  `form:"options=US,CA,MX"`
+	Size     string  `form:"type=radio;oneof=S,M,L"`
+	Quantity int     `form:"min=1;max=10"`
+	Price    float64 `form:"max=99.99"`
+	Gift     bool
+	Zip      string `form:"name=zip_code;pattern=[0-9]{5}"`
+	Tags     []string
+	Address  struct {
+		Street string `form:"required"`
+	}
+	Lines []struct {
+		Item  string `form:"oneof=shirt,mug"`
+		Count int    `form:"name=count"`
+	}
+	Meta     map[string]string
+	Delivery time.Time `form:"type=date"`
+	Colour   hexColour
+	Internal string `form:"-"`
+}
+
+func TestSchema(t *testing.T) {
+	const (
+		wantFile = "TestSchema.golden"
+		gotFile  = "TestSchema.got"
+	)
+	got, err := form.Schema(swagOrder{})
+	if err != nil {
+		t.Fatalf("Schema() err = %v; want nil", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(got, &doc); err != nil {
+		t.Fatalf("Schema() returned invalid JSON: %v", err)
+	}
+	os.Remove(gotFile)
+	if updateFlag {
+		writeFile(t, wantFile, string(got))
+		t.Logf("Updated golden file %s", wantFile)
+	}
+	want := readFile(t, wantFile)
+	if string(got) != string(want) {
+		t.Errorf("Schema() - results do not match golden file.")
+		writeFile(t, gotFile, string(got))
+		t.Errorf("  To compare run: diff %s %s", gotFile, wantFile)
+	}
+}
+
+func TestSchema_invalidTags(t *testing.T) {
+	_, err := form.Schema(struct {
+		Name string `form:"max=lots"`
+	}{})
+	if err == nil {
+		t.Errorf("Schema() err = nil; want an error")
+	}
+	if err != nil && !strings.Contains(err.Error(), "Name") {
+		t.Errorf("Schema() err = %v; want it to mention the field", err)
+	}
+}
+
+type schemaNode struct {
+	Name     string `form:"required"`
+	Children []schemaNode
+}
+
+func TestSchema_recursiveTypes(t *testing.T) {
+	tests := map[string]struct {
+		strct interface{}
+		want  []string
+	}{
+		"recursive": {
+			strct: schemaNode{},
+			want:  []string{"Name"},
+		},
+		"nested recursive": {
+			strct: struct {
+				Root schemaNode
+			}{},
+			want: []string{"Root.Name"},
+		},
+		"repeated but not recursive": {
+			strct: struct {
+				Left, Right schemaNode
+			}{},
+			want: []string{"Left.Name", "Right.Name"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := form.Schema(tc.strct)
+			if err != nil {
+				t.Fatalf("Schema() err = %v; want nil", err)
+			}
+			var doc struct {
+				Properties        map[string]interface{} `json:"properties"`
+				PatternProperties map[string]interface{} `json:"patternProperties"`
+			}
+			if err := json.Unmarshal(got, &doc); err != nil {
+				t.Fatalf("Schema() returned invalid JSON: %v", err)
+			}
+			if len(doc.Properties) != len(tc.want) {
+				t.Errorf("Schema() properties = %v; want %v", doc.Properties, tc.want)
+			}
+			for _, name := range tc.want {
+				if _, ok := doc.Properties[name]; !ok {
+					t.Errorf("Schema() properties = %v; want %q", doc.Properties, name)
+				}
+			}
+			if len(doc.PatternProperties) != 0 {
+				t.Errorf("Schema() patternProperties = %v; want none", doc.PatternProperties)
+			}
+		})
+	}
+}
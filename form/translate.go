@@ -0,0 +1,139 @@
+package form
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Translator translates the text shown to users in forms. The text to
+// translate is always the English default, which is one of:
+//
+//   - a label or placeholder, eg "Full Name"
+//   - the label of an option, eg "Canada"
+//   - a validation message format, eg "%[1]s is required", where %[1]s is
+//     the field's label and any further verbs are the rule's arguments
+//   - a decoding message format, eg "%[1]s must be a whole number", where
+//     %[1]s is the field's label
+//
+// Translate should return text unchanged when it has no translation.
+type Translator interface {
+	Translate(text string) string
+}
+
+// Dictionary is a Translator that looks up translations in a map keyed by
+// the English text.
+//
+//	fr := form.Dictionary{
+//	  "Full Name":          "Nom complet",
+//	  "%[1]s is required": "%[1]s est obligatoire",
+//	}
+type Dictionary map[string]string
+
+// Translate returns the translation of text, or text if d doesn't have one.
+func (d Dictionary) Translate(text string) string {
+	if t, ok := d[text]; ok {
+		return t
+	}
+	return text
+}
+
+// Languages maps language tags, such as "fr" or "pt-BR", to the Translator
+// used for that language.
+type Languages map[string]Translator
+
+// ForRequest returns the Translator for the language r's Accept-Language
+// header prefers most, or nil if none of its languages are in l. Regional
+// languages fall back to their base language, so "fr-CA" uses the "fr"
+// Translator when there isn't one for "fr-CA".
+func (l Languages) ForRequest(r *http.Request) Translator {
+	for _, tag := range acceptedLanguages(r.Header.Get("Accept-Language")) {
+		if tr := l.lookup(tag); tr != nil {
+			return tr
+		}
+		if i := strings.Index(tag, "-"); i > 0 {
+			if tr := l.lookup(tag[:i]); tr != nil {
+				return tr
+			}
+		}
+	}
+	return nil
+}
+
+func (l Languages) lookup(tag string) Translator {
+	if tr, ok := l[tag]; ok {
+		return tr
+	}
+	// Language tags are case insensitive.
+	for t, tr := range l {
+		if strings.EqualFold(t, tag) {
+			return tr
+		}
+	}
+	return nil
+}
+
+// acceptedLanguages returns the language tags in an Accept-Language header
+// ordered from most to least preferred. Wildcards and languages with a
+// quality of zero are left out.
+func acceptedLanguages(header string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var langs []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params := part, ""
+		if i := strings.Index(part, ";"); i >= 0 {
+			tag, params = part[:i], part[i+1:]
+		}
+		tag = strings.TrimSpace(tag)
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			v, err := strconv.ParseFloat(params[len("q="):], 64)
+			if err != nil {
+				continue
+			}
+			q = v
+		}
+		if tag == "" || tag == "*" || q <= 0 {
+			continue
+		}
+		langs = append(langs, language{tag, q})
+	}
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+	tags := make([]string, len(langs))
+	for i, lang := range langs {
+		tags[i] = lang.tag
+	}
+	return tags
+}
+
+// translate returns the translation of text using tr, which may be nil.
+func translate(tr Translator, text string) string {
+	if tr == nil || text == "" {
+		return text
+	}
+	return tr.Translate(text)
+}
+
+// translate translates the text of f that is shown to users.
+func (f *field) translate(tr Translator) {
+	if tr == nil {
+		return
+	}
+	f.Label = translate(tr, f.Label)
+	f.Placeholder = translate(tr, f.Placeholder)
+	if len(f.Options) == 0 {
+		return
+	}
+	opts := make([]Option, len(f.Options))
+	for i, o := range f.Options {
+		o.Label = translate(tr, o.Label)
+		opts[i] = o
+	}
+	f.Options = opts
+}
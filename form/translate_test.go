@@ -0,0 +1,164 @@
+package form_test
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joncalhoun/twg/form"
+)
+
+var (
+	french = form.Dictionary{
+		"Full Name":                    "Nom complet",
+		"Your name":                    "Votre nom",
+		"Canada":                       "Canada (FR)",
+		"Quantity":                     "Quantité",
+		"%[1]s is required":            "%[1]s est obligatoire",
+		"%[1]s must be a whole number": "%[1]s doit être un nombre entier",
+		"%[1]s must be at least %[2]s characters": "%[1]s doit contenir au moins %[2]s caractères",
+	}
+	german = form.Dictionary{
+		"Full Name": "Vollständiger Name",
+	}
+	languages = form.Languages{
+		"fr":    french,
+		"de-AT": german,
+	}
+)
+
+func TestLanguages_ForRequest(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		want   form.Translator
+	}{
+		"no header":            {"", nil},
+		"exact match":          {"fr", french},
+		"regional fallback":    {"fr-CA", french},
+		"case insensitive":     {"DE-at", german},
+		"no base match":        {"de", nil},
+		"first match wins":     {"es, fr, de-AT", french},
+		"quality ordering":     {"fr;q=0.5, de-AT;q=0.8", german},
+		"zero quality ignored": {"de-AT;q=0, fr;q=0.1", french},
+		"wildcard ignored":     {"*, fr;q=0.2", french},
+		"no match":             {"es, it;q=0.9", nil},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tc.header != "" {
+				r.Header.Set("Accept-Language", tc.header)
+			}
+			got := languages.ForRequest(r)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ForRequest() = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+type translatedForm struct {
+	Name    string `form:"label=Full Name;placeholder=Your name;required;min=3"`
+	Country string `form:"type=radio;options=Canada"`
+	Email   string
+}
+
+func TestBuilder_WithLanguage(t *testing.T) {
+	var b form.Builder
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "fr-CA,fr;q=0.9,en;q=0.8")
+	fr := b.WithLanguage(languages, r)
+
+	html, err := fr.HTML(translatedForm{})
+	if err != nil {
+		t.Fatalf("HTML() err = %v; want nil", err)
+	}
+	for _, want := range []string{
+		`>Nom complet</label>`,
+		`placeholder="Votre nom"`,
+		` Canada (FR)</label>`,
+		// Text without a translation is left alone.
+		`>Email</label>`,
+	} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("HTML() = %s; want it to contain %s", html, want)
+		}
+	}
+
+	got, err := fr.Validate(translatedForm{})
+	if err != nil {
+		t.Fatalf("Validate() err = %v; want nil", err)
+	}
+	want := []form.FieldError{
+		{Field: "Name", Error: "Nom complet est obligatoire"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v; want %v", got, want)
+	}
+	got, _ = fr.Validate(translatedForm{Name: "Al"})
+	want = []form.FieldError{
+		{Field: "Name", Error: "Nom complet doit contenir au moins 3 caractères"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v; want %v", got, want)
+	}
+
+	// The original Builder should not be changed.
+	html, err = b.HTML(translatedForm{})
+	if err != nil {
+		t.Fatalf("HTML() err = %v; want nil", err)
+	}
+	if !strings.Contains(string(html), `>Full Name</label>`) {
+		t.Errorf("HTML() = %s; want an untranslated label", html)
+	}
+	got, _ = b.Validate(translatedForm{})
+	want, _ = form.Validate(translatedForm{})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v; want %v", got, want)
+	}
+}
+
+func TestBuilder_WithLanguage_noMatch(t *testing.T) {
+	b := form.Builder{Translator: german}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "es")
+	html, err := b.WithLanguage(languages, r).HTML(translatedForm{})
+	if err != nil {
+		t.Fatalf("HTML() err = %v; want nil", err)
+	}
+	if !strings.Contains(string(html), `>Vollständiger Name</label>`) {
+		t.Errorf("HTML() = %s; want the Builder's own Translator to be used", html)
+	}
+}
+
+func TestBuilder_Decode(t *testing.T) {
+	type order struct {
+		Quantity int
+		Price    float64
+	}
+	values := url.Values{"Quantity": {"lots"}, "Price": {"cheap"}}
+
+	fr := form.Builder{Translator: french}
+	var dst order
+	got, err := fr.Decode(postForm(values), &dst)
+	if err != nil {
+		t.Fatalf("Decode() err = %v; want nil", err)
+	}
+	want := []form.FieldError{
+		{Field: "Quantity", Error: "Quantité doit être un nombre entier"},
+		// Messages without a translation are left in English.
+		{Field: "Price", Error: "Price must be a number"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %v; want %v", got, want)
+	}
+
+	var b form.Builder
+	got, _ = b.Decode(postForm(values), &dst)
+	want, _ = form.Decode(postForm(values), &dst)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %v; want %v", got, want)
+	}
+}
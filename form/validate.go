@@ -0,0 +1,227 @@
+package form
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Validate checks the values in strct against the validation rules declared
+// in its form struct tags and returns a FieldError for every rule that is
+// broken. The FieldErrors use the same field names as HTML, so they can be
+// passed straight into it to re-render an invalid form with messages:
+//
+//	errs, err := form.Validate(&order)
+//	if err != nil {
+//	  ...
+//	}
+//	if len(errs) > 0 {
+//	  html, err := form.HTML(tpl, &order, errs...)
+//	  ...
+//	}
+//
+// Rules are added to the form struct tag alongside any other tags, eg
+// `form:"label=Email Address;required;email"`. The supported rules are:
+//
+//	required       the value must not be empty
+//	min=N          numbers must be at least N, strings at least N characters
+//	max=N          numbers must be at most N, strings at most N characters
+//	email          the value must be an email address
+//	pattern=REGEX  the value must match the regular expression
+//	oneof=A,B,C    the value must be one of the comma separated options
+//
+// Apart from required, rules are not checked against empty strings so that
+// optional fields can be left blank.
+//
+// Messages are in English. Use a Builder's Validate method to translate them
+// with its Translator.
+//
+// The error is only non-nil when strct has an invalid struct tag or rule,
+// such as a min rule that isn't a number, just as it is for HTML and Decode.
+func Validate(strct interface{}) ([]FieldError, error) {
+	return validate(strct, nil)
+}
+
+// validate is Validate with messages and labels translated by tr, which may
+// be nil.
+func validate(strct interface{}, tr Translator) ([]FieldError, error) {
+	fields, err := parseFields(strct)
+	if err != nil {
+		return nil, err
+	}
+	var errors []FieldError
+	for _, f := range fields {
+		for _, msg := range f.validate() {
+			args := append([]interface{}{translate(tr, f.Label)}, msg.args...)
+			errors = append(errors, FieldError{
+				Field: f.Name,
+				Error: fmt.Sprintf(translate(tr, msg.format), args...),
+			})
+		}
+	}
+	return errors, nil
+}
+
+// ruleNames are the struct tags that declare validation rules.
+var ruleNames = []string{"required", "min", "max", "email", "pattern", "oneof"}
+
+// message is a validation message that hasn't been formatted yet. The format
+// is formatted with the field's label followed by args, and is also the text
+// that gets translated.
+type message struct {
+	format string
+	args   []interface{}
+}
+
+// validate returns a message for each of the field's rules that its value
+// breaks.
+func (f *field) validate() []message {
+	rv := reflect.ValueOf(f.Value)
+	if _, ok := f.rules["required"]; ok && isEmpty(rv) {
+		return []message{{format: "%[1]s is required"}}
+	}
+	if rv.Kind() == reflect.String && rv.Len() == 0 {
+		return nil
+	}
+	var msgs []message
+	if v, ok := f.rules["min"]; ok {
+		min, _ := strconv.ParseFloat(v, 64)
+		if n, unit := measure(rv); n < min {
+			msgs = append(msgs, message{"%[1]s must be at least %[2]s" + unit, []interface{}{v}})
+		}
+	}
+	if v, ok := f.rules["max"]; ok {
+		max, _ := strconv.ParseFloat(v, 64)
+		if n, unit := measure(rv); n > max {
+			msgs = append(msgs, message{"%[1]s must be at most %[2]s" + unit, []interface{}{v}})
+		}
+	}
+	if _, ok := f.rules["email"]; ok {
+		s := fmt.Sprint(f.Value)
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			msgs = append(msgs, message{format: "%[1]s must be a valid email address"})
+		}
+	}
+	if v, ok := f.rules["pattern"]; ok {
+		re := compilePattern(v)
+		if !re.MatchString(fmt.Sprint(f.Value)) {
+			msgs = append(msgs, message{format: "%[1]s is not in the correct format"})
+		}
+	}
+	if v, ok := f.rules["oneof"]; ok {
+		options := strings.Split(v, ",")
+		if !contains(options, fmt.Sprint(f.Value)) {
+			msgs = append(msgs, message{"%[1]s must be one of %[2]s", []interface{}{strings.Join(options, ", ")}})
+		}
+	}
+	return msgs
+}
+
+// measure returns the number that min and max rules are compared against
+// along with the unit to use in error messages. Strings are measured by
+// their length in characters, numbers by their value.
+func measure(rv reflect.Value) (float64, string) {
+	switch rv.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(rv.String())), " characters"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), ""
+	default:
+		panic(fmt.Sprintf("form: min and max rules are not supported for %v values", rv.Kind()))
+	}
+}
+
+func isEmpty(rv reflect.Value) bool {
+	if !rv.IsValid() {
+		return true
+	}
+	return rv.IsZero()
+}
+
+// checkRules returns an error if any of the validation rules in tags can't
+// be used with the field sf.
+func checkRules(sf reflect.StructField, tags map[string]string) error {
+	invalid := func(rule, reason string) error {
+		return fmt.Errorf("form: invalid %s rule %q on field %s: %s", rule, tags[rule], sf.Name, reason)
+	}
+	for _, rule := range []string{"min", "max"} {
+		v, ok := tags[rule]
+		if !ok {
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return invalid(rule, "must be a number")
+		}
+		if !measurable(sf.Type) {
+			return invalid(rule, fmt.Sprintf("not supported for %v fields", sf.Type))
+		}
+	}
+	if v, ok := tags["pattern"]; ok {
+		if _, err := regexp.Compile(anchor(v)); err != nil {
+			return invalid("pattern", err.Error())
+		}
+	}
+	return nil
+}
+
+// measurable reports whether min and max rules can be used with fields of
+// type t. Rules on slices, arrays and maps apply to each of their elements.
+func measurable(t reflect.Type) bool {
+	t = indirectType(t)
+	switch {
+	case isCustom(t):
+	case t.Kind() == reflect.Slice, t.Kind() == reflect.Array, t.Kind() == reflect.Map:
+		t = indirectType(t.Elem())
+	}
+	// FormValuers are rendered as strings, so rules measure their length.
+	if t.Implements(formValuerType) || reflect.PtrTo(t).Implements(formValuerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// patterns caches compiled pattern rules so that validating the same struct
+// type repeatedly doesn't recompile them. Patterns are checked when a type's
+// plan is built, so they are known to compile.
+var patterns sync.Map // map[string]*regexp.Regexp
+
+func compilePattern(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(anchor(pattern))
+	patterns.Store(pattern, re)
+	return re
+}
+
+// anchor makes sure a pattern rule matches the entire value rather than
+// just part of it.
+func anchor(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
+
+func contains(options []string, v string) bool {
+	for _, o := range options {
+		if o == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package form_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/joncalhoun/twg/form"
+)
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		strct interface{}
+		want  []form.FieldError
+	}{
+		"No rules": {
+			strct: struct {
+				Name string
+			}{},
+			want: nil,
+		},
+		"Valid values": {
+			strct: struct {
+				Name     string `form:"required;min=2;max=20"`
+				Email    string `form:"required;email"`
+				Quantity int    `form:"min=1;max=10"`
+				Country  string `form:"oneof=US,CA,MX"`
+				Zip      string `form:"pattern=[0-9]{5}"`
+			}{
+				Name:     "Michael Scott",
+				Email:    "michael@dundermifflin.com",
+				Quantity: 3,
+				Country:  "CA",
+				Zip:      "18503",
+			},
+			want: nil,
+		},
+		"Required": {
+			strct: struct {
+				Name    string `form:"label=Full Name;required"`
+				Age     int    `form:"required"`
+				Terms   bool   `form:"required"`
+				Comment *string
+				Address struct {
+					Street string `form:"required"`
+				}
+			}{},
+			want: []form.FieldError{
+				{Field: "Name", Error: "Full Name is required"},
+				{Field: "Age", Error: "Age is required"},
+				{Field: "Terms", Error: "Terms is required"},
+				{Field: "Address.Street", Error: "Street is required"},
+			},
+		},
+		"Min and max": {
+			strct: struct {
+				Name     string  `form:"min=3"`
+				Password string  `form:"name=pw;max=4"`
+				Quantity int     `form:"min=1"`
+				Price    float64 `form:"max=9.99"`
+			}{
+				Name:     "Mo",
+				Password: "hunter2",
+				Quantity: 0,
+				Price:    10,
+			},
+			want: []form.FieldError{
+				{Field: "Name", Error: "Name must be at least 3 characters"},
+				{Field: "pw", Error: "Password must be at most 4 characters"},
+				{Field: "Quantity", Error: "Quantity must be at least 1"},
+				{Field: "Price", Error: "Price must be at most 9.99"},
+			},
+		},
+		"Email, pattern and oneof": {
+			strct: struct {
+				Email   string `form:"email"`
+				Zip     string `form:"pattern=[0-9]{5}"`
+				Country string `form:"oneof=US,CA,MX"`
+			}{
+				Email:   "Michael <michael@dundermifflin.com>",
+				Zip:     "185030",
+				Country: "UK",
+			},
+			want: []form.FieldError{
+				{Field: "Email", Error: "Email must be a valid email address"},
+				{Field: "Zip", Error: "Zip is not in the correct format"},
+				{Field: "Country", Error: "Country must be one of US, CA, MX"},
+			},
+		},
+		"Empty optional values are not checked": {
+			strct: struct {
+				Email string `form:"email;min=5"`
+				Zip   string `form:"pattern=[0-9]{5}"`
+			}{},
+			want: nil,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := form.Validate(tc.strct)
+			if err != nil {
+				t.Fatalf("Validate() err = %v; want nil", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Validate() = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidate_invalidRules(t *testing.T) {
+	tests := map[string]interface{}{
+		"min": struct {
+			Name string `form:"min=three"`
+		}{Name: "Jon"},
+		"pattern": struct {
+			Name string `form:"pattern=[a-z"`
+		}{Name: "Jon"},
+		"unsupported type": struct {
+			Gift bool `form:"max=2"`
+		}{Gift: true},
+	}
+	for name, strct := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := form.Validate(strct)
+			if err == nil {
+				t.Errorf("Validate() err = nil; want an error")
+			}
+			if got != nil {
+				t.Errorf("Validate() = %v; want nil", got)
+			}
+			if _, err := (&form.Builder{}).Validate(strct); err == nil {
+				t.Errorf("Builder.Validate() err = nil; want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package form
+
+import (
+	"reflect"
+	"time"
+)
+
+// FormValuer is implemented by types that control how they are rendered.
+// Fields of these types are always rendered as a single input, even if they
+// are structs or slices, and FormValue returns the input's value.
+type FormValuer interface {
+	FormValue() string
+}
+
+// FormUnmarshaler is implemented by types that control how they are decoded.
+// Fields of these types are always decoded from a single input, and
+// UnmarshalForm is called with the submitted value unless it is empty, in
+// which case the field is set to its zero value like any other.
+//
+// Errors returned by UnmarshalForm become FieldErrors with the field's label
+// in front of them, so they should read like "must be a valid colour".
+type FormUnmarshaler interface {
+	UnmarshalForm(value string) error
+}
+
+// inputValue returns the value used to render rv in an input of type typ.
+// Types implementing FormValuer provide their own value, and time.Time
+// values are formatted the way date and datetime-local inputs expect.
+func inputValue(rv reflect.Value, typ string) interface{} {
+	if v, ok := asInterface(rv, formValuerType); ok {
+		return v.(FormValuer).FormValue()
+	}
+	if rv.Type() == timeType {
+		t := rv.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		switch typ {
+		case "date":
+			return t.Format("2006-01-02")
+		case "datetime-local":
+			return t.Format("2006-01-02T15:04")
+		}
+		return t.Format(time.RFC3339)
+	}
+	return rv.Interface()
+}
+
+// asInterface returns rv as the interface type iface if either rv or a
+// pointer to it implements iface. Values that aren't addressable are copied
+// so that methods with pointer receivers can still be used.
+func asInterface(rv reflect.Value, iface reflect.Type) (interface{}, bool) {
+	if rv.Type().Implements(iface) {
+		return rv.Interface(), true
+	}
+	if !reflect.PtrTo(rv.Type()).Implements(iface) {
+		return nil, false
+	}
+	if rv.CanAddr() {
+		return rv.Addr().Interface(), true
+	}
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	return ptr.Interface(), true
+}
@@ -1,34 +1,57 @@
 package stripe
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
-	Version         = "2018-09-24"
+	Version = "2018-09-24"
+
+	// PaymentIntentsVersion is the API version used for PaymentIntents. The
+	// requires_action status and next_action field are named
+	// requires_source_action and next_source_action in Version.
+	PaymentIntentsVersion = "2019-02-11"
+
+	// SubscriptionsVersion is the API version used for subscriptions. In
+	// Version, creating a subscription whose first payment fails returns an
+	// error rather than an incomplete subscription.
+	SubscriptionsVersion = "2019-03-14"
+
 	DefaultCurrency = "usd"
 	DefaultBaseURL  = "https://api.stripe.com/v1"
 )
 
-type Customer struct {
-	ID            string `json:"id"`
-	DefaultSource string `json:"default_source"`
-	Email         string `json:"email"`
+type Charge struct {
+	ID                  string            `json:"id"`
+	Amount              int               `json:"amount"`
+	Created             int64             `json:"created"`
+	Currency            Currency          `json:"currency"`
+	Customer            string            `json:"customer"`
+	Description         string            `json:"description"`
+	FailureCode         string            `json:"failure_code"`
+	FailureMessage      string            `json:"failure_message"`
+	Livemode            bool              `json:"livemode"`
+	Metadata            map[string]string `json:"metadata"`
+	Paid                bool              `json:"paid"`
+	ReceiptEmail        string            `json:"receipt_email"`
+	StatementDescriptor string            `json:"statement_descriptor"`
+	Status              string            `json:"status"`
+	AmountRefunded      int               `json:"amount_refunded"`
+	Refunded            bool              `json:"refunded"`
 }
 
-type Charge struct {
-	ID             string `json:"id"`
-	Amount         int    `json:"amount"`
-	FailureCode    string `json:"failure_code"`
-	FailureMessage string `json:"failure_message"`
-	Paid           bool   `json:"paid"`
-	Status         string `json:"status"`
+// Money returns the amount of chg in its currency.
+func (chg *Charge) Money() Money {
+	return Money{Amount: chg.Amount, Currency: chg.Currency}
 }
 
 type Client struct {
@@ -37,6 +60,11 @@ type Client struct {
 	HttpClient interface {
 		Do(*http.Request) (*http.Response, error)
 	}
+	// Retry is the policy used to retry requests that fail for temporary
+	// reasons. Requests are not retried if it is nil.
+	Retry *RetryPolicy
+	// Hooks are called after every request the Client makes. See Hook.
+	Hooks []Hook
 }
 
 func (c *Client) do(req *http.Request) (*http.Response, error) {
@@ -44,7 +72,9 @@ func (c *Client) do(req *http.Request) (*http.Response, error) {
 	if httpClient == nil {
 		httpClient = &http.Client{}
 	}
-	req.Header.Set("Stripe-Version", Version)
+	if req.Header.Get("Stripe-Version") == "" {
+		req.Header.Set("Stripe-Version", Version)
+	}
 	if req.Method != http.MethodGet {
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
@@ -59,71 +89,188 @@ func (c *Client) url(path string) string {
 	return fmt.Sprintf("%s%s", c.BaseURL, path)
 }
 
-func (c *Client) Customer(token, email string) (*Customer, error) {
-	endpoint := c.url("/customers")
+// Charge charges the customer with the given ID amount in DefaultCurrency.
+// Use ChargeMoney to charge in any other currency.
+func (c *Client) Charge(customerID string, amount int, opts ...RequestOption) (*Charge, error) {
+	return c.ChargeContext(context.Background(), customerID, amount, opts...)
+}
+
+// ChargeContext is like Charge, but ctx is used to cancel its requests.
+func (c *Client) ChargeContext(ctx context.Context, customerID string, amount int, opts ...RequestOption) (*Charge, error) {
+	return c.ChargeMoneyContext(ctx, customerID, Money{Amount: amount, Currency: DefaultCurrency}, opts...)
+}
+
+// ChargeMoney charges the customer with the given ID amount, in amount's
+// currency.
+func (c *Client) ChargeMoney(customerID string, amount Money, opts ...RequestOption) (*Charge, error) {
+	return c.ChargeMoneyContext(context.Background(), customerID, amount, opts...)
+}
+
+// ChargeMoneyContext is like ChargeMoney, but ctx is used to cancel its
+// requests.
+func (c *Client) ChargeMoneyContext(ctx context.Context, customerID string, amount Money, opts ...RequestOption) (*Charge, error) {
+	return c.CreateChargeContext(ctx, &ChargeParams{Customer: customerID, Amount: amount}, opts...)
+}
+
+// ChargeParams are the fields used to create a charge. Customer and Amount
+// are required, while the other fields are only sent if they are non-empty.
+type ChargeParams struct {
+	// Customer is the ID of the customer to charge, using their default
+	// payment source.
+	Customer string
+	// Amount is the amount to charge. Its Currency defaults to
+	// DefaultCurrency.
+	Amount Money
+	// Description is an arbitrary string shown alongside the charge in the
+	// Stripe dashboard and on the customer's receipt.
+	Description string
+	// StatementDescriptor is shown on the customer's card statement. It must
+	// be at most 22 characters long and can't contain any of <>"'.
+	StatementDescriptor string
+	// ReceiptEmail is the address Stripe emails a receipt to.
+	ReceiptEmail string
+	// Metadata is a set of key-value pairs stored with the charge, such as an
+	// order ID.
+	Metadata map[string]string
+}
+
+func (p *ChargeParams) values() url.Values {
 	v := url.Values{}
-	v.Set("source", token)
-	v.Set("email", email)
-	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(v.Encode()))
-	if err != nil {
-		return nil, err
+	v.Set("customer", p.Customer)
+	v.Set("amount", strconv.Itoa(p.Amount.Amount))
+	v.Set("currency", DefaultCurrency)
+	if p.Amount.Currency != "" {
+		v.Set("currency", string(p.Amount.Currency.code()))
 	}
-	res, err := c.do(req)
-	if err != nil {
-		return nil, err
+	if p.Description != "" {
+		v.Set("description", p.Description)
 	}
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+	if p.StatementDescriptor != "" {
+		v.Set("statement_descriptor", p.StatementDescriptor)
 	}
-	if res.StatusCode >= 400 {
-		return nil, parseError(body)
+	if p.ReceiptEmail != "" {
+		v.Set("receipt_email", p.ReceiptEmail)
 	}
-	var cus Customer
-	err = json.Unmarshal(body, &cus)
-	if err != nil {
+	setMetadata(v, p.Metadata)
+	return v
+}
+
+// CreateCharge creates a charge using params.
+func (c *Client) CreateCharge(params *ChargeParams, opts ...RequestOption) (*Charge, error) {
+	return c.CreateChargeContext(context.Background(), params, opts...)
+}
+
+// CreateChargeContext is like CreateCharge, but ctx is used to cancel its
+// requests.
+func (c *Client) CreateChargeContext(ctx context.Context, params *ChargeParams, opts ...RequestOption) (*Charge, error) {
+	var chg Charge
+	if err := c.call(ctx, http.MethodPost, "/charges", params.values(), &chg, opts); err != nil {
 		return nil, err
 	}
-	return &cus, nil
+	return &chg, nil
 }
 
-func (c *Client) Charge(customerID string, amount int) (*Charge, error) {
-	endpoint := c.url("/charges")
-	v := url.Values{}
-	v.Set("customer", customerID)
-	v.Set("amount", strconv.Itoa(amount))
-	v.Set("currency", DefaultCurrency)
-	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(v.Encode()))
-	if err != nil {
-		return nil, err
+// setMetadata adds metadata to v using Stripe's metadata[key]=value format.
+func setMetadata(v url.Values, metadata map[string]string) {
+	for key, value := range metadata {
+		v.Set("metadata["+key+"]", value)
 	}
-	res, err := c.do(req)
-	if err != nil {
-		return nil, err
+}
+
+// call makes a request to the Stripe API and decodes the response body into
+// v. params are sent as the query string of GET and DELETE requests and as
+// the body of any others, which are also given an idempotency key. GET and
+// DELETE requests are already idempotent, so Stripe ignores keys sent with
+// them and none is sent. Responses with an error status are returned as an
+// Error, and failures to reach Stripe as a ConnectionError. ctx applies to
+// every attempt, including any waits between them.
+func (c *Client) call(ctx context.Context, method, path string, params url.Values, v interface{}, opts []RequestOption) error {
+	o := newRequestOptions(opts)
+	endpoint := c.url(path)
+	var body, key string
+	switch method {
+	case http.MethodGet, http.MethodDelete:
+		if len(params) > 0 {
+			endpoint += "?" + params.Encode()
+		}
+	default:
+		body = params.Encode()
+		// The key is chosen once so that every attempt of this call uses it.
+		key = o.idempotencyKey
+		if key == "" {
+			var err error
+			key, err = newIdempotencyKey()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	var res *http.Response
+	for attempt := 1; ; attempt++ {
+		var r io.Reader
+		if body != "" {
+			r = strings.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, r)
+		if err != nil {
+			return err
+		}
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		if o.version != "" {
+			req.Header.Set("Stripe-Version", o.version)
+		}
+		start := time.Now()
+		res, err = c.do(req)
+		c.afterRequest(ctx, path, params, req, attempt, res, err, time.Since(start))
+		wait, retry := c.Retry.retry(req, attempt, res, err)
+		if !retry {
+			if err != nil {
+				return connectionError(ctx, err)
+			}
+			break
+		}
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+		if err := c.Retry.sleep(ctx, wait); err != nil {
+			return err
+		}
 	}
 	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
+	data, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
+		return connectionError(ctx, err)
 	}
 	if res.StatusCode >= 400 {
-		return nil, parseError(body)
+		return parseError(res, data)
 	}
+	return json.Unmarshal(data, v)
+}
 
-	var chg Charge
-	err = json.Unmarshal(body, &chg)
-	if err != nil {
-		return nil, err
+// parseError returns the Error in data, the body of res. Bodies that aren't
+// a Stripe error, such as an HTML page from a proxy, still give an Error with
+// the response's status and request ID, along with a generic message.
+func parseError(res *http.Response, data []byte) error {
+	var se Error
+	if err := json.Unmarshal(data, &se); err != nil || se.Message == "" {
+		se = Error{
+			Message: fmt.Sprintf("stripe: unexpected response: %s", res.Status),
+		}
 	}
-	return &chg, nil
+	se.StatusCode = res.StatusCode
+	se.RequestID = res.Header.Get("Request-Id")
+	return se
 }
 
-func parseError(data []byte) error {
-	var se Error
-	err := json.Unmarshal(data, &se)
-	if err != nil {
+// connectionError wraps err, which occurred while sending a request or
+// reading its response, in a ConnectionError. Errors caused by ctx are
+// returned as they are, as they aren't a problem with the connection.
+func connectionError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
 		return err
 	}
-	return se
+	return &ConnectionError{Err: err}
 }
@@ -1,17 +1,18 @@
 package stripe_test
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
+	"github.com/joncalhoun/twg/cassette"
 	"github.com/joncalhoun/twg/stripe"
 )
 
@@ -24,6 +25,7 @@ const (
 	tokenAmex               = "tok_amex"
 	tokenVisaDebit          = "tok_visa_debit"
 	tokenMastercardPrepaid  = "tok_mastercard_prepaid"
+	tokenVisa               = "tok_visa"
 	tokenInvalid            = "tok_alsdkjfa"
 	tokenExpiredCard        = "tok_chargeDeclinedExpiredCard"
 	tokenIncorrectCVC       = "tok_chargeDeclinedIncorrectCvc"
@@ -90,81 +92,174 @@ func TestClient_Local(t *testing.T) {
 	}
 }
 
-func stripeClient(t *testing.T) (*stripe.Client, func()) {
-	teardown := make([]func(), 0)
+func TestClient_IdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(stripe.IdempotencyKeyHeader))
+		fmt.Fprint(w, `{"id": "cus_123"}`)
+	}))
+	defer server.Close()
 	c := stripe.Client{
-		Key: apiKey,
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
 	}
-	if apiKey == "" {
-		count := 0
-		handler := func(w http.ResponseWriter, r *http.Request) {
-			resp := readResponse(t, count)
-			w.WriteHeader(resp.StatusCode)
-			w.Write(resp.Body)
-			count++
-		}
-		server := httptest.NewServer(http.HandlerFunc(handler))
-		c.BaseURL = server.URL
-		teardown = append(teardown, server.Close)
-	}
-	if update {
-		rc := &recorderClient{}
-		c.HttpClient = rc
-		teardown = append(teardown, func() {
-			for i, res := range rc.responses {
-				recordResponse(t, res, i)
-			}
-		})
+
+	c.Customer(tokenAmex, "test@testwithgo.com")
+	c.Customer(tokenAmex, "test@testwithgo.com")
+	c.Customer(tokenAmex, "test@testwithgo.com", stripe.IdempotencyKey("order-123"))
+	c.DeleteCustomer("cus_123")
+	c.GetCustomer("cus_123", stripe.IdempotencyKey("order-123"))
+	if len(keys) != 5 {
+		t.Fatalf("requests = %d; want 5", len(keys))
 	}
-	return &c, func() {
-		for _, fn := range teardown {
-			fn()
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for _, i := range []int{0, 1} {
+		if !uuid.MatchString(keys[i]) {
+			t.Errorf("request %d key = %q; want a random UUID", i, keys[i])
 		}
 	}
+	if keys[0] == keys[1] {
+		t.Errorf("generated keys = %q and %q; want them to differ", keys[0], keys[1])
+	}
+	if keys[2] != "order-123" {
+		t.Errorf("key = %q; want %q", keys[2], "order-123")
+	}
+	if keys[3] != "" {
+		t.Errorf("DELETE key = %q; want none", keys[3])
+	}
+	if keys[4] != "" {
+		t.Errorf("GET key = %q; want none", keys[4])
+	}
 }
 
-func responsePath(t *testing.T, count int) string {
-	return filepath.Join("testdata", filepath.FromSlash(fmt.Sprintf("%s.%d.json", t.Name(), count)))
+func TestClient_APIVersion(t *testing.T) {
+	var versions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versions = append(versions, r.Header.Get("Stripe-Version"))
+		fmt.Fprint(w, `{"id": "obj_123"}`)
+	}))
+	defer server.Close()
+	c := stripe.Client{
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
+	}
+
+	tests := map[string]struct {
+		call func()
+		want string
+	}{
+		"default": {
+			call: func() { c.GetCustomer("cus_123") },
+			want: stripe.Version,
+		},
+		"payment intents": {
+			call: func() { c.GetPaymentIntent("pi_123") },
+			want: stripe.PaymentIntentsVersion,
+		},
+		"subscriptions": {
+			call: func() { c.GetSubscription("sub_123") },
+			want: stripe.SubscriptionsVersion,
+		},
+		"get invoice": {
+			call: func() { c.GetInvoice("in_123") },
+			want: stripe.SubscriptionsVersion,
+		},
+		"pay invoice": {
+			call: func() { c.PayInvoice("in_123") },
+			want: stripe.SubscriptionsVersion,
+		},
+		"list invoices": {
+			call: func() { c.ListInvoices("cus_123", nil).Next() },
+			want: stripe.SubscriptionsVersion,
+		},
+		"option": {
+			call: func() { c.GetCustomer("cus_123", stripe.APIVersion("2020-08-27")) },
+			want: "2020-08-27",
+		},
+		"option overrides method's version": {
+			call: func() { c.GetPaymentIntent("pi_123", stripe.APIVersion("2020-08-27")) },
+			want: "2020-08-27",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			versions = nil
+			tc.call()
+			if len(versions) != 1 || versions[0] != tc.want {
+				t.Errorf("Stripe-Version = %v; want %s", versions, tc.want)
+			}
+		})
+	}
 }
 
-func readResponse(t *testing.T, count int) response {
-	var resp response
-	path := responsePath(t, count)
-	f, err := os.Open(path)
-	if err != nil {
-		t.Fatalf("failed to open the response file: %s. err = %v", path, err)
+func TestClient_DefaultCurrency(t *testing.T) {
+	var currencies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		currencies = append(currencies, r.PostFormValue("currency"))
+		fmt.Fprint(w, `{"id": "obj_123"}`)
+	}))
+	defer server.Close()
+	c := stripe.Client{
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
 	}
-	defer f.Close()
-	jsonBytes, err := ioutil.ReadAll(f)
-	if err != nil {
-		t.Fatalf("failed to read the response file: %s. err = %v", path, err)
+
+	tests := map[string]struct {
+		call func()
+		want string
+	}{
+		"charge": {
+			call: func() { c.CreateCharge(&stripe.ChargeParams{Customer: "cus_123", Amount: stripe.Money{Amount: 1234}}) },
+			want: stripe.DefaultCurrency,
+		},
+		"charge in euros": {
+			call: func() {
+				c.CreateCharge(&stripe.ChargeParams{Customer: "cus_123", Amount: stripe.Money{Amount: 1234, Currency: stripe.EUR}})
+			},
+			want: "eur",
+		},
+		"price": {
+			call: func() {
+				c.CreatePrice(&stripe.PriceParams{Product: "prod_123", UnitAmount: stripe.Money{Amount: 1234}})
+			},
+			want: stripe.DefaultCurrency,
+		},
 	}
-	err = json.Unmarshal(jsonBytes, &resp)
-	if err != nil {
-		t.Fatalf("failed to json unmarshal the response file: %s. err = %v", path, err)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			currencies = nil
+			tc.call()
+			if len(currencies) != 1 || currencies[0] != tc.want {
+				t.Errorf("currency = %v; want %s", currencies, tc.want)
+			}
+		})
 	}
-	return resp
 }
 
-func recordResponse(t *testing.T, resp response, count int) {
-	path := responsePath(t, count)
-	err := os.MkdirAll(filepath.Dir(path), 0700)
-	if err != nil {
-		t.Fatalf("failed to create the response dir: %s. err = %v", filepath.Dir(path), err)
+// stripeClient returns a Client for tests that talk to Stripe. Without the
+// key flag, requests are answered from the cassette in testdata named after
+// the test, while the update flag records a new cassette from the real API.
+func stripeClient(t *testing.T) (*stripe.Client, func()) {
+	c := stripe.Client{
+		Key: apiKey,
 	}
-	f, err := os.Create(path)
-	if err != nil {
-		t.Fatalf("failed to create the response file: %s. err = %v", path, err)
+	mode := cassette.Replay
+	switch {
+	case update:
+		mode = cassette.Record
+	case apiKey != "":
+		return &c, func() {}
 	}
-	defer f.Close()
-	jsonBytes, err := json.MarshalIndent(resp, "", "  ")
-	if err != nil {
-		t.Fatalf("failed to marshal JSON for response file: %s. err = %v", path, err)
-	}
-	_, err = f.Write(jsonBytes)
-	if err != nil {
-		t.Fatalf("failed to write json bytes for response file: %s. err = %v", path, err)
+	path := filepath.Join("testdata", filepath.FromSlash(t.Name()+".json"))
+	if _, err := os.Stat(path); mode == cassette.Replay && os.IsNotExist(err) {
+		t.Skipf("No recorded responses in %s. Record them with -update -key=sk_test_...", path)
 	}
+	cas := cassette.New(t, path, mode)
+	// Responses differ between API versions, so a request is only answered by
+	// one recorded with the same version.
+	cas.MatchHeaders = []string{"Stripe-Version"}
+	c.HttpClient = cas
+	return &c, cas.Close
 }
 
 func TestClient_Customer(t *testing.T) {
@@ -348,3 +443,73 @@ func TestClient_Charge(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_ChargeMoney(t *testing.T) {
+	tests := map[string]struct {
+		amount stripe.Money
+		want   string
+	}{
+		"euros":            {stripe.Money{Amount: 2500, Currency: stripe.EUR}, "€25.00"},
+		"pounds":           {stripe.Money{Amount: 1999, Currency: stripe.GBP}, "£19.99"},
+		"zero-decimal yen": {stripe.Money{Amount: 1200, Currency: stripe.JPY}, "¥1,200"},
+		"upper case code":  {stripe.Money{Amount: 2500, Currency: "EUR"}, "€25.00"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, teardown := stripeClient(t)
+			defer teardown()
+			cus := createCustomer(t, c, tokenAmex)
+			chg, err := c.ChargeMoney(cus.ID, tc.amount)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if got := chg.Money().String(); got != tc.want {
+				t.Errorf("Money() = %s; want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClient_CreateCharge(t *testing.T) {
+	t.Run("with details", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		cus := createCustomer(t, c, tokenAmex)
+		params := &stripe.ChargeParams{
+			Customer:            cus.ID,
+			Amount:              stripe.Money{Amount: 2500, Currency: stripe.EUR},
+			Description:         "Swag order 123",
+			StatementDescriptor: "TWG SWAG",
+			ReceiptEmail:        "receipts@testwithgo.com",
+			Metadata:            map[string]string{"order_id": "123"},
+		}
+		chg, err := c.CreateCharge(params)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		got := stripe.Charge{
+			Customer:            chg.Customer,
+			Amount:              chg.Amount,
+			Currency:            chg.Currency,
+			Description:         chg.Description,
+			StatementDescriptor: chg.StatementDescriptor,
+			ReceiptEmail:        chg.ReceiptEmail,
+			Metadata:            chg.Metadata,
+		}
+		want := stripe.Charge{
+			Customer:            cus.ID,
+			Amount:              2500,
+			Currency:            stripe.EUR,
+			Description:         params.Description,
+			StatementDescriptor: params.StatementDescriptor,
+			ReceiptEmail:        params.ReceiptEmail,
+			Metadata:            params.Metadata,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("CreateCharge() = %+v; want %+v", got, want)
+		}
+		if chg.Created == 0 {
+			t.Errorf("Created = 0; want the creation time")
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package stripe_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+func TestClient_Context(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Respond only once the test is over, so every request has to be
+		// stopped by its context.
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+		fmt.Fprint(w, `{"id": "cus_123"}`)
+	}))
+	defer server.Close()
+	defer close(done)
+	c := stripe.Client{
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.CustomerContext(ctx, tokenAmex, "test@testwithgo.com")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("CustomerContext() err = %v; want %v", err, context.Canceled)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = c.ChargeContext(ctx, "cus_123", 1234)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ChargeContext() err = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("ChargeContext() took %v; want it to stop at the deadline", elapsed)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	it := c.ListCustomersContext(ctx, nil)
+	if it.Next() {
+		t.Errorf("Next() = true; want false")
+	}
+	if !errors.Is(it.Err(), context.Canceled) {
+		t.Errorf("Err() = %v; want %v", it.Err(), context.Canceled)
+	}
+}
+
+func TestClient_Context_retryWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error": {"type": "rate_limit_error", "message": "Too many requests."}}`)
+	}))
+	defer server.Close()
+	c := stripe.Client{
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
+		Retry:   &stripe.RetryPolicy{},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetCustomerContext(ctx, "cus_123")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetCustomerContext() err = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("GetCustomerContext() took %v; want the retry wait cut short", elapsed)
+	}
+}
@@ -0,0 +1,170 @@
+package stripe
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type Customer struct {
+	ID            string            `json:"id"`
+	Created       int64             `json:"created"`
+	Currency      Currency          `json:"currency"`
+	DefaultSource string            `json:"default_source"`
+	Description   string            `json:"description"`
+	Email         string            `json:"email"`
+	Livemode      bool              `json:"livemode"`
+	Metadata      map[string]string `json:"metadata"`
+	// Deleted is only true for customers that have been deleted, which can
+	// still be retrieved with GetCustomer.
+	Deleted bool `json:"deleted"`
+}
+
+// CustomerParams are the fields that can be set when creating or updating a
+// customer. Empty fields are left unchanged.
+type CustomerParams struct {
+	// Email is the customer's email address.
+	Email string
+	// Source is a token, such as one from Stripe.js, that replaces the
+	// customer's default payment source.
+	Source string
+	// Description is an arbitrary string shown alongside the customer in the
+	// Stripe dashboard.
+	Description string
+	// Metadata is a set of key-value pairs stored with the customer. When
+	// updating, keys that aren't in Metadata are left alone, and a key set to
+	// an empty value is removed.
+	Metadata map[string]string
+}
+
+func (p *CustomerParams) values() url.Values {
+	v := url.Values{}
+	if p == nil {
+		return v
+	}
+	if p.Email != "" {
+		v.Set("email", p.Email)
+	}
+	if p.Source != "" {
+		v.Set("source", p.Source)
+	}
+	if p.Description != "" {
+		v.Set("description", p.Description)
+	}
+	setMetadata(v, p.Metadata)
+	return v
+}
+
+// Customer creates a customer with the given email address, whose default
+// payment source is the card that token stands for.
+func (c *Client) Customer(token, email string, opts ...RequestOption) (*Customer, error) {
+	return c.CustomerContext(context.Background(), token, email, opts...)
+}
+
+// CustomerContext is like Customer, but ctx is used to cancel its requests.
+func (c *Client) CustomerContext(ctx context.Context, token, email string, opts ...RequestOption) (*Customer, error) {
+	return c.CreateCustomerContext(ctx, &CustomerParams{Source: token, Email: email}, opts...)
+}
+
+// CreateCustomer creates a customer using the non-empty fields of params.
+func (c *Client) CreateCustomer(params *CustomerParams, opts ...RequestOption) (*Customer, error) {
+	return c.CreateCustomerContext(context.Background(), params, opts...)
+}
+
+// CreateCustomerContext is like CreateCustomer, but ctx is used to cancel its
+// requests.
+func (c *Client) CreateCustomerContext(ctx context.Context, params *CustomerParams, opts ...RequestOption) (*Customer, error) {
+	var cus Customer
+	if err := c.call(ctx, http.MethodPost, "/customers", params.values(), &cus, opts); err != nil {
+		return nil, err
+	}
+	return &cus, nil
+}
+
+// GetCustomer retrieves the customer with the given ID.
+func (c *Client) GetCustomer(id string, opts ...RequestOption) (*Customer, error) {
+	return c.GetCustomerContext(context.Background(), id, opts...)
+}
+
+// GetCustomerContext is like GetCustomer, but ctx is used to cancel its
+// requests.
+func (c *Client) GetCustomerContext(ctx context.Context, id string, opts ...RequestOption) (*Customer, error) {
+	var cus Customer
+	if err := c.call(ctx, http.MethodGet, "/customers/"+url.PathEscape(id), nil, &cus, opts); err != nil {
+		return nil, err
+	}
+	return &cus, nil
+}
+
+// UpdateCustomer updates the customer with the given ID using the non-empty
+// fields of params and returns the updated customer.
+func (c *Client) UpdateCustomer(id string, params *CustomerParams, opts ...RequestOption) (*Customer, error) {
+	return c.UpdateCustomerContext(context.Background(), id, params, opts...)
+}
+
+// UpdateCustomerContext is like UpdateCustomer, but ctx is used to cancel its
+// requests.
+func (c *Client) UpdateCustomerContext(ctx context.Context, id string, params *CustomerParams, opts ...RequestOption) (*Customer, error) {
+	var cus Customer
+	if err := c.call(ctx, http.MethodPost, "/customers/"+url.PathEscape(id), params.values(), &cus, opts); err != nil {
+		return nil, err
+	}
+	return &cus, nil
+}
+
+// DeleteCustomer permanently deletes the customer with the given ID and
+// cancels any of their subscriptions.
+func (c *Client) DeleteCustomer(id string, opts ...RequestOption) error {
+	return c.DeleteCustomerContext(context.Background(), id, opts...)
+}
+
+// DeleteCustomerContext is like DeleteCustomer, but ctx is used to cancel its
+// requests.
+func (c *Client) DeleteCustomerContext(ctx context.Context, id string, opts ...RequestOption) error {
+	var cus Customer
+	return c.call(ctx, http.MethodDelete, "/customers/"+url.PathEscape(id), nil, &cus, opts)
+}
+
+// ListCustomers returns an iterator over every customer, newest first. Pages
+// of customers are fetched as they are needed:
+//
+//	it := c.ListCustomers(nil)
+//	for it.Next() {
+//	  cus := it.Customer()
+//	  ...
+//	}
+//	if err := it.Err(); err != nil {
+//	  ...
+//	}
+func (c *Client) ListCustomers(params *ListParams, opts ...RequestOption) *CustomerIter {
+	return c.ListCustomersContext(context.Background(), params, opts...)
+}
+
+// ListCustomersContext is like ListCustomers, but ctx is used to cancel its
+// requests.
+func (c *Client) ListCustomersContext(ctx context.Context, params *ListParams, opts ...RequestOption) *CustomerIter {
+	return &CustomerIter{iter: newIter(ctx, c, "/customers", params, opts)}
+}
+
+// CustomerIter iterates over a list of customers. See ListCustomers.
+type CustomerIter struct {
+	*iter
+	cus *Customer
+}
+
+// Next advances to the next customer, fetching the next page of customers
+// if needed. It returns false when there are no more customers or an error
+// occurs, which is then returned by Err.
+func (it *CustomerIter) Next() bool {
+	var cus Customer
+	if !it.next(&cus) {
+		return false
+	}
+	it.cus = &cus
+	return true
+}
+
+// Customer returns the current customer.
+func (it *CustomerIter) Customer() *Customer {
+	return it.cus
+}
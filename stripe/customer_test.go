@@ -0,0 +1,267 @@
+package stripe_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+func createCustomer(t *testing.T, c *stripe.Client, token string) *stripe.Customer {
+	cus, err := c.Customer(token, "test@testwithgo.com")
+	if err != nil {
+		t.Fatalf("err creating customer with token %s. err = %v; want nil", token, err)
+	}
+	return cus
+}
+
+func hasStripeErr(t *testing.T, err error, typee string) {
+	se, ok := err.(stripe.Error)
+	if !ok {
+		t.Fatalf("err isn't a stripe.Error")
+	}
+	if se.Type != typee {
+		t.Errorf("err.Type = %s; want %s", se.Type, typee)
+	}
+}
+
+func TestClient_GetCustomer(t *testing.T) {
+	t.Run("existing customer", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		created := createCustomer(t, c, tokenAmex)
+		cus, err := c.GetCustomer(created.ID)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if !reflect.DeepEqual(cus, created) {
+			t.Errorf("GetCustomer() = %+v; want %+v", cus, created)
+		}
+	})
+	t.Run("missing customer", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		_, err := c.GetCustomer("cus_missing")
+		hasStripeErr(t, err, stripe.ErrTypeInvalidRequest)
+	})
+}
+
+func TestClient_UpdateCustomer(t *testing.T) {
+	type checkFn func(*testing.T, *stripe.Customer, *stripe.Customer, error)
+	check := func(fns ...checkFn) []checkFn { return fns }
+
+	hasNoErr := func() checkFn {
+		return func(t *testing.T, before, cus *stripe.Customer, err error) {
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+		}
+	}
+	hasErrType := func(typee string) checkFn {
+		return func(t *testing.T, before, cus *stripe.Customer, err error) {
+			hasStripeErr(t, err, typee)
+		}
+	}
+	hasEmail := func(email string) checkFn {
+		return func(t *testing.T, before, cus *stripe.Customer, err error) {
+			if cus.Email != email {
+				t.Errorf("Email = %s; want %s", cus.Email, email)
+			}
+		}
+	}
+	hasNewDefaultSource := func() checkFn {
+		return func(t *testing.T, before, cus *stripe.Customer, err error) {
+			if !strings.HasPrefix(cus.DefaultSource, "card_") {
+				t.Errorf("DefaultSource = %s; want prefix %q", cus.DefaultSource, "card_")
+			}
+			if cus.DefaultSource == before.DefaultSource {
+				t.Errorf("DefaultSource = %s; want it to change", cus.DefaultSource)
+			}
+		}
+	}
+
+	tests := map[string]struct {
+		create bool
+		params *stripe.CustomerParams
+		checks []checkFn
+	}{
+		"new email": {
+			create: true,
+			params: &stripe.CustomerParams{Email: "updated@testwithgo.com"},
+			checks: check(hasNoErr(), hasEmail("updated@testwithgo.com")),
+		},
+		"new source": {
+			create: true,
+			params: &stripe.CustomerParams{Source: tokenVisaDebit},
+			checks: check(hasNoErr(), hasEmail("test@testwithgo.com"), hasNewDefaultSource()),
+		},
+		"expired card": {
+			create: true,
+			params: &stripe.CustomerParams{Source: tokenExpiredCard},
+			checks: check(hasErrType(stripe.ErrTypeCardError)),
+		},
+		"missing customer": {
+			params: &stripe.CustomerParams{Email: "updated@testwithgo.com"},
+			checks: check(hasErrType(stripe.ErrTypeInvalidRequest)),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, teardown := stripeClient(t)
+			defer teardown()
+			before := &stripe.Customer{ID: "cus_missing"}
+			if tc.create {
+				before = createCustomer(t, c, tokenAmex)
+			}
+			cus, err := c.UpdateCustomer(before.ID, tc.params)
+			for _, check := range tc.checks {
+				check(t, before, cus, err)
+			}
+		})
+	}
+}
+
+func TestClient_DeleteCustomer(t *testing.T) {
+	t.Run("existing customer", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		created := createCustomer(t, c, tokenAmex)
+		err := c.DeleteCustomer(created.ID)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		cus, err := c.GetCustomer(created.ID)
+		if err != nil {
+			t.Fatalf("GetCustomer() err = %v; want nil", err)
+		}
+		if !cus.Deleted {
+			t.Errorf("Deleted = %t; want %t", cus.Deleted, true)
+		}
+	})
+	t.Run("missing customer", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		err := c.DeleteCustomer("cus_missing")
+		hasStripeErr(t, err, stripe.ErrTypeInvalidRequest)
+	})
+}
+
+func TestClient_ListCustomers(t *testing.T) {
+	t.Run("paginates", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		var want []string
+		for _, token := range []string{tokenAmex, tokenVisaDebit, tokenMastercardPrepaid} {
+			want = append([]string{createCustomer(t, c, token).ID}, want...)
+		}
+		// Customers are listed newest first, so with two per page the three
+		// we just created are spread over the first two pages.
+		it := c.ListCustomers(&stripe.ListParams{Limit: 2})
+		var got []string
+		for len(got) < len(want) && it.Next() {
+			got = append(got, it.Customer().ID)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("Err() = %v; want nil", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("customer IDs = %v; want %v", got, want)
+		}
+	})
+}
+
+func TestClient_ListCustomers_Local(t *testing.T) {
+	pages := map[string]string{
+		"":          `{"object": "list", "data": [{"id": "cus_1"}, {"id": "cus_2"}], "has_more": true}`,
+		"cus_2":     `{"object": "list", "data": [{"id": "cus_3"}], "has_more": false}`,
+		"cus_error": `{"error": {"type": "api_error", "message": "Something went wrong."}}`,
+	}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.String())
+		if r.Method != http.MethodGet || r.URL.Path != "/customers" {
+			t.Errorf("request = %s %s; want GET /customers", r.Method, r.URL.Path)
+		}
+		after := r.URL.Query().Get("starting_after")
+		if after == "cus_error" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		fmt.Fprint(w, pages[after])
+	}))
+	defer server.Close()
+	c := stripe.Client{
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
+	}
+
+	it := c.ListCustomers(&stripe.ListParams{Limit: 2})
+	var got []string
+	for it.Next() {
+		got = append(got, it.Customer().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v; want nil", err)
+	}
+	if want := []string{"cus_1", "cus_2", "cus_3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("customer IDs = %v; want %v", got, want)
+	}
+	wantRequests := []string{
+		"/customers?limit=2",
+		"/customers?limit=2&starting_after=cus_2",
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("requests = %v; want %v", requests, wantRequests)
+	}
+
+	it = c.ListCustomers(&stripe.ListParams{StartingAfter: "cus_error"})
+	if it.Next() {
+		t.Errorf("Next() = true; want false")
+	}
+	if _, ok := it.Err().(stripe.Error); !ok {
+		t.Errorf("Err() = %v; want a stripe.Error", it.Err())
+	}
+}
+
+func TestClient_CreateCustomer(t *testing.T) {
+	t.Run("with metadata", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		params := &stripe.CustomerParams{
+			Email:       "test@testwithgo.com",
+			Source:      tokenAmex,
+			Description: "Swag store customer",
+			Metadata:    map[string]string{"user_id": "123", "plan": "gold"},
+		}
+		cus, err := c.CreateCustomer(params)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if cus.Description != params.Description {
+			t.Errorf("Description = %s; want %s", cus.Description, params.Description)
+		}
+		if !reflect.DeepEqual(cus.Metadata, params.Metadata) {
+			t.Errorf("Metadata = %v; want %v", cus.Metadata, params.Metadata)
+		}
+		if cus.Created == 0 {
+			t.Errorf("Created = 0; want the creation time")
+		}
+		if cus.Livemode {
+			t.Errorf("Livemode = true; want false with a test key")
+		}
+
+		// Updating a key to an empty value removes it.
+		cus, err = c.UpdateCustomer(cus.ID, &stripe.CustomerParams{
+			Metadata: map[string]string{"plan": ""},
+		})
+		if err != nil {
+			t.Fatalf("UpdateCustomer() err = %v; want nil", err)
+		}
+		if want := map[string]string{"user_id": "123"}; !reflect.DeepEqual(cus.Metadata, want) {
+			t.Errorf("Metadata = %v; want %v", cus.Metadata, want)
+		}
+	})
+}
@@ -2,57 +2,121 @@ package stripe
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 )
 
 const (
 	ErrTypeCardError      = "card_error"
 	ErrTypeInvalidRequest = "invalid_request_error"
+	ErrTypeAuthentication = "authentication_error"
 )
 
+// Errors that can be compared with those returned by Client using errors.Is,
+// so callers don't need to inspect an Error's Type and Code themselves:
+//
+//	if errors.Is(err, stripe.ErrInsufficientFunds) {
+//		// Ask for another card.
+//	}
+//
+// ErrCardDeclined matches every card_error, including those that also match
+// ErrExpiredCard, ErrIncorrectCVC or ErrInsufficientFunds. ErrAPIConnection
+// matches a ConnectionError rather than an Error.
+var (
+	ErrCardDeclined         = errors.New("stripe: card declined")
+	ErrExpiredCard          = errors.New("stripe: expired card")
+	ErrIncorrectCVC         = errors.New("stripe: incorrect cvc")
+	ErrInsufficientFunds    = errors.New("stripe: insufficient funds")
+	ErrRateLimited          = errors.New("stripe: rate limited")
+	ErrAuthenticationFailed = errors.New("stripe: authentication failed")
+	ErrAPIConnection        = errors.New("stripe: could not connect to the api")
+)
+
+// Error is an error response from the Stripe API.
 type Error struct {
-	Code    string `json:"code"`
-	DocURL  string `json:"doc_url"`
-	Message string `json:"message"`
-	Param   string `json:"param"`
-	Type    string `json:"type"`
+	Code        string `json:"code"`
+	DeclineCode string `json:"decline_code,omitempty"`
+	DocURL      string `json:"doc_url"`
+	Message     string `json:"message"`
+	Param       string `json:"param"`
+	Type        string `json:"type"`
+
+	// StatusCode is the HTTP status of the response and RequestID is its
+	// Request-Id header, which Stripe support can use to find the request.
+	// Neither is part of the response body.
+	StatusCode int    `json:"-"`
+	RequestID  string `json:"-"`
 }
 
 func (err Error) Error() string {
+	if err.DocURL == "" {
+		return err.Message
+	}
 	return fmt.Sprintf("%s See %s for more information.", err.Message, err.DocURL)
 }
 
+// Is reports whether err matches target, one of the Err variables above.
+func (err Error) Is(target error) bool {
+	switch target {
+	case ErrCardDeclined:
+		return err.Type == ErrTypeCardError
+	case ErrExpiredCard:
+		return err.Code == "expired_card"
+	case ErrIncorrectCVC:
+		return err.Code == "incorrect_cvc"
+	case ErrInsufficientFunds:
+		return err.DeclineCode == "insufficient_funds"
+	case ErrRateLimited:
+		return err.StatusCode == http.StatusTooManyRequests || err.Code == "rate_limit"
+	case ErrAuthenticationFailed:
+		return err.StatusCode == http.StatusUnauthorized || err.Type == ErrTypeAuthentication
+	}
+	return false
+}
+
+// errorFields has the fields of Error without its methods, so that it can be
+// marshalled without recursing.
+type errorFields Error
+
 func (err Error) MarshalJSON() ([]byte, error) {
 	var tmp struct {
-		Error struct {
-			Code    string `json:"code"`
-			DocURL  string `json:"doc_url"`
-			Message string `json:"message"`
-			Param   string `json:"param"`
-			Type    string `json:"type"`
-		} `json:"error"`
+		Error errorFields `json:"error"`
 	}
-	tmp.Error = err
+	tmp.Error = errorFields(err)
 	return json.Marshal(tmp)
 }
 
 func (err *Error) UnmarshalJSON(data []byte) error {
 	var tmp struct {
-		Error struct {
-			Code    string `json:"code"`
-			DocURL  string `json:"doc_url"`
-			Message string `json:"message"`
-			Param   string `json:"param"`
-			Type    string `json:"type"`
-		} `json:"error"`
+		Error errorFields `json:"error"`
 	}
 	if err := json.Unmarshal(data, &tmp); err != nil {
 		return err
 	}
-	*err = tmp.Error
+	*err = Error(tmp.Error)
 	return nil
 }
 
+// ConnectionError is returned when a request could not be sent to Stripe, or
+// its response could not be read, and it matches ErrAPIConnection. Err is the
+// underlying error.
+type ConnectionError struct {
+	Err error
+}
+
+func (err *ConnectionError) Error() string {
+	return fmt.Sprintf("stripe: could not connect to the api: %v", err.Err)
+}
+
+func (err *ConnectionError) Unwrap() error {
+	return err.Err
+}
+
+func (err *ConnectionError) Is(target error) bool {
+	return target == ErrAPIConnection
+}
+
 type AutoGenerated struct {
 	Error struct {
 		Code    string `json:"code"`
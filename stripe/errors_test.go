@@ -2,6 +2,10 @@ package stripe_test
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/joncalhoun/twg/stripe"
@@ -59,3 +63,152 @@ func TestError_Marshal(t *testing.T) {
 		t.Log("Is Unmarshal working? It is required for this test to pass.")
 	}
 }
+
+func TestError_Is(t *testing.T) {
+	all := []error{
+		stripe.ErrCardDeclined,
+		stripe.ErrExpiredCard,
+		stripe.ErrIncorrectCVC,
+		stripe.ErrInsufficientFunds,
+		stripe.ErrRateLimited,
+		stripe.ErrAuthenticationFailed,
+		stripe.ErrAPIConnection,
+	}
+	tests := map[string]struct {
+		err  error
+		want []error
+	}{
+		"expired card": {
+			err:  stripe.Error{Type: stripe.ErrTypeCardError, Code: "expired_card", StatusCode: 402},
+			want: []error{stripe.ErrCardDeclined, stripe.ErrExpiredCard},
+		},
+		"incorrect cvc": {
+			err:  stripe.Error{Type: stripe.ErrTypeCardError, Code: "incorrect_cvc", StatusCode: 402},
+			want: []error{stripe.ErrCardDeclined, stripe.ErrIncorrectCVC},
+		},
+		"insufficient funds": {
+			err:  stripe.Error{Type: stripe.ErrTypeCardError, Code: "card_declined", DeclineCode: "insufficient_funds", StatusCode: 402},
+			want: []error{stripe.ErrCardDeclined, stripe.ErrInsufficientFunds},
+		},
+		"rate limited": {
+			err:  stripe.Error{Type: stripe.ErrTypeInvalidRequest, Code: "rate_limit", StatusCode: 429},
+			want: []error{stripe.ErrRateLimited},
+		},
+		"authentication failed": {
+			err:  stripe.Error{Type: stripe.ErrTypeInvalidRequest, StatusCode: 401},
+			want: []error{stripe.ErrAuthenticationFailed},
+		},
+		"missing resource": {
+			err: stripe.Error{Type: stripe.ErrTypeInvalidRequest, Code: "resource_missing", StatusCode: 404},
+		},
+		"connection": {
+			err:  &stripe.ConnectionError{Err: errors.New("connection refused")},
+			want: []error{stripe.ErrAPIConnection},
+		},
+		"wrapped": {
+			err:  fmt.Errorf("charging customer: %w", stripe.Error{Type: stripe.ErrTypeCardError}),
+			want: []error{stripe.ErrCardDeclined},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, target := range all {
+				want := false
+				for _, w := range tc.want {
+					want = want || w == target
+				}
+				if got := errors.Is(tc.err, target); got != want {
+					t.Errorf("errors.Is(%v) = %t; want %t", target, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Request-Id", "req_123")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"type": "invalid_request_error", "code": "rate_limit", "message": "Too many requests."}}`))
+	}))
+	c := stripe.Client{
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
+	}
+	_, err := c.GetCustomer("cus_123")
+	var se stripe.Error
+	if !errors.As(err, &se) {
+		t.Fatalf("err = %v; want a stripe.Error", err)
+	}
+	if se.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("StatusCode = %d; want %d", se.StatusCode, http.StatusTooManyRequests)
+	}
+	if se.RequestID != "req_123" {
+		t.Errorf("RequestID = %s; want %s", se.RequestID, "req_123")
+	}
+	if !errors.Is(err, stripe.ErrRateLimited) {
+		t.Errorf("errors.Is(ErrRateLimited) = false; want true")
+	}
+
+	server.Close()
+	_, err = c.GetCustomer("cus_123")
+	if !errors.Is(err, stripe.ErrAPIConnection) {
+		t.Errorf("err = %v; want %v", err, stripe.ErrAPIConnection)
+	}
+	var ce *stripe.ConnectionError
+	if !errors.As(err, &ce) || ce.Err == nil {
+		t.Errorf("err = %v; want a *stripe.ConnectionError", err)
+	}
+}
+
+func TestClient_errors_notJSON(t *testing.T) {
+	tests := map[string]struct {
+		status int
+		body   string
+		target error
+	}{
+		"bad gateway": {
+			status: http.StatusBadGateway,
+			body:   "<html><body><h1>502 Bad Gateway</h1></body></html>",
+		},
+		"empty": {
+			status: http.StatusServiceUnavailable,
+		},
+		"unauthorized": {
+			status: http.StatusUnauthorized,
+			body:   "Unauthorized",
+			target: stripe.ErrAuthenticationFailed,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Request-Id", "req_123")
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+			c := stripe.Client{
+				Key:     "gibberish-key",
+				BaseURL: server.URL,
+			}
+			_, err := c.GetCustomer("cus_123")
+			var se stripe.Error
+			if !errors.As(err, &se) {
+				t.Fatalf("err = %v; want a stripe.Error", err)
+			}
+			if se.StatusCode != tc.status {
+				t.Errorf("StatusCode = %d; want %d", se.StatusCode, tc.status)
+			}
+			if se.RequestID != "req_123" {
+				t.Errorf("RequestID = %s; want %s", se.RequestID, "req_123")
+			}
+			if se.Message == "" {
+				t.Errorf("Message is empty; want a generic message")
+			}
+			if tc.target != nil && !errors.Is(err, tc.target) {
+				t.Errorf("errors.Is(%v) = false; want true", tc.target)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package stripe_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+// checkoutErrorMessage returns a message to show a customer whose payment
+// failed with err. Details of the error are left out unless the customer can
+// act on them, as they are meant for us rather than for them.
+func checkoutErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, stripe.ErrExpiredCard):
+		return "Your card has expired. Please use a different card."
+	case errors.Is(err, stripe.ErrIncorrectCVC):
+		return "Your card's security code is incorrect. Please check it and try again."
+	case errors.Is(err, stripe.ErrInsufficientFunds):
+		return "Your card has insufficient funds. Please use a different card."
+	case errors.Is(err, stripe.ErrCardDeclined):
+		return "Your card was declined. Please use a different card."
+	case errors.Is(err, stripe.ErrRateLimited), errors.Is(err, stripe.ErrAPIConnection):
+		return "We couldn't reach our payment provider. Please try again in a few minutes."
+	default:
+		return "Something went wrong processing your payment. Please contact support if the problem persists."
+	}
+}
+
+func Example_errors() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		fmt.Fprint(w, `{"error": {"type": "card_error", "code": "card_declined", "decline_code": "generic_decline", "message": "Your card was declined."}}`)
+	}))
+	c := stripe.Client{
+		Key:     "sk_test_123",
+		BaseURL: server.URL,
+	}
+
+	_, err := c.Charge("cus_123", 1000)
+	fmt.Println(checkoutErrorMessage(err))
+
+	server.Close()
+	_, err = c.Charge("cus_123", 1000)
+	fmt.Println(checkoutErrorMessage(err))
+
+	fmt.Println(checkoutErrorMessage(errors.New("something unexpected")))
+
+	// Output:
+	// Your card was declined. Please use a different card.
+	// We couldn't reach our payment provider. Please try again in a few minutes.
+	// Something went wrong processing your payment. Please contact support if the problem persists.
+}
@@ -0,0 +1,525 @@
+// Package fakestripe provides an in-memory fake of the customers and charges
+// parts of the Stripe API, so that whole flows such as a checkout can be
+// tested without a network connection or a Stripe account:
+//
+//	ts := httptest.NewServer(fakestripe.New())
+//	defer ts.Close()
+//	c := &stripe.Client{Key: "sk_test_fake", BaseURL: ts.URL + "/v1"}
+//
+// The fake understands the test tokens listed at
+// https://stripe.com/docs/testing, such as tok_amex, and declines cards with
+// the same errors Stripe uses. tok_chargeCustomerFail can be attached to a
+// customer, but every charge made with it fails.
+package fakestripe
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is an http.Handler that fakes the Stripe API. It accepts any
+// non-empty API key and it is safe for concurrent use. The zero value is not
+// ready to use; use New instead.
+type Server struct {
+	// Now returns the time used for created timestamps and defaults to
+	// time.Now.
+	Now func() time.Time
+
+	mu          sync.Mutex
+	customers   map[string]*customerObject
+	customerIDs []string // oldest first
+	charges     map[string]*chargeObject
+	chargeIDs   []string // oldest first
+	cards       map[string]card
+	idempotent  map[string]recorded
+}
+
+// recorded is a response saved for an idempotency key.
+type recorded struct {
+	status int
+	body   []byte
+}
+
+func New() *Server {
+	return &Server{
+		customers:  make(map[string]*customerObject),
+		charges:    make(map[string]*chargeObject),
+		cards:      make(map[string]card),
+		idempotent: make(map[string]recorded),
+	}
+}
+
+type cardObject struct {
+	ID       string  `json:"id"`
+	Object   string  `json:"object"`
+	Brand    string  `json:"brand"`
+	Country  string  `json:"country"`
+	Customer *string `json:"customer"`
+	ExpMonth int     `json:"exp_month"`
+	ExpYear  int     `json:"exp_year"`
+	Funding  string  `json:"funding"`
+	Last4    string  `json:"last4"`
+}
+
+type listObject struct {
+	Object  string        `json:"object"`
+	Data    []interface{} `json:"data"`
+	HasMore bool          `json:"has_more"`
+	URL     string        `json:"url"`
+}
+
+type customerObject struct {
+	ID            string            `json:"id"`
+	Object        string            `json:"object"`
+	Created       int64             `json:"created"`
+	DefaultSource *string           `json:"default_source"`
+	Description   *string           `json:"description"`
+	Email         *string           `json:"email"`
+	Livemode      bool              `json:"livemode"`
+	Metadata      map[string]string `json:"metadata"`
+	Sources       listObject        `json:"sources"`
+
+	deleted bool
+}
+
+type deletedObject struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Deleted bool   `json:"deleted"`
+}
+
+type chargeObject struct {
+	ID                  string            `json:"id"`
+	Object              string            `json:"object"`
+	Amount              int               `json:"amount"`
+	AmountRefunded      int               `json:"amount_refunded"`
+	Captured            bool              `json:"captured"`
+	Created             int64             `json:"created"`
+	Currency            string            `json:"currency"`
+	Customer            *string           `json:"customer"`
+	Description         *string           `json:"description"`
+	FailureCode         *string           `json:"failure_code"`
+	FailureMessage      *string           `json:"failure_message"`
+	Livemode            bool              `json:"livemode"`
+	Metadata            map[string]string `json:"metadata"`
+	Paid                bool              `json:"paid"`
+	ReceiptEmail        *string           `json:"receipt_email"`
+	Refunded            bool              `json:"refunded"`
+	Source              *cardObject       `json:"source"`
+	StatementDescriptor *string           `json:"statement_descriptor"`
+	Status              string            `json:"status"`
+}
+
+// apiError is an error response in the same format as Stripe's.
+type apiError struct {
+	status int
+
+	Charge      string `json:"charge,omitempty"`
+	Code        string `json:"code,omitempty"`
+	DeclineCode string `json:"decline_code,omitempty"`
+	DocURL      string `json:"doc_url,omitempty"`
+	Message     string `json:"message"`
+	Param       string `json:"param,omitempty"`
+	Type        string `json:"type"`
+}
+
+func invalidRequest(status int, code, param, format string, args ...interface{}) *apiError {
+	return &apiError{
+		status:  status,
+		Type:    "invalid_request_error",
+		Code:    code,
+		Param:   param,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func noSuch(status int, kind, id, param string) *apiError {
+	return invalidRequest(status, "resource_missing", param, "No such %s: %s", kind, id)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if key, _, _ := r.BasicAuth(); key == "" {
+		s.writeError(w, &apiError{
+			status:  http.StatusUnauthorized,
+			Type:    "invalid_request_error",
+			Message: "You did not provide an API key. You need to provide your API key in the Authorization header, using Bearer auth (e.g. 'Authorization: Bearer YOUR_SECRET_KEY').",
+		})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		s.writeError(w, invalidRequest(http.StatusBadRequest, "", "", "Invalid request body."))
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.Header.Get("Idempotency-Key")
+	if r.Method == http.MethodPost && key != "" {
+		if rec, ok := s.idempotent[key]; ok {
+			w.Header().Set("Idempotent-Replayed", "true")
+			s.write(w, rec.status, rec.body)
+			return
+		}
+	}
+	status, body := s.route(r)
+	if r.Method == http.MethodPost && key != "" {
+		s.idempotent[key] = recorded{status: status, body: body}
+	}
+	s.write(w, status, body)
+}
+
+// route handles r and returns the response status and body.
+func (s *Server) route(r *http.Request) (int, []byte) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var v interface{}
+	var err *apiError
+	switch {
+	case len(parts) == 1 && parts[0] == "customers" && r.Method == http.MethodPost:
+		v, err = s.createCustomer(r)
+	case len(parts) == 1 && parts[0] == "customers" && r.Method == http.MethodGet:
+		v, err = s.listCustomers(r)
+	case len(parts) == 2 && parts[0] == "customers" && r.Method == http.MethodGet:
+		v, err = s.getCustomer(parts[1])
+	case len(parts) == 2 && parts[0] == "customers" && r.Method == http.MethodPost:
+		v, err = s.updateCustomer(parts[1], r)
+	case len(parts) == 2 && parts[0] == "customers" && r.Method == http.MethodDelete:
+		v, err = s.deleteCustomer(parts[1])
+	case len(parts) == 1 && parts[0] == "charges" && r.Method == http.MethodPost:
+		v, err = s.createCharge(r)
+	case len(parts) == 1 && parts[0] == "charges" && r.Method == http.MethodGet:
+		v, err = s.listCharges(r)
+	case len(parts) == 2 && parts[0] == "charges" && r.Method == http.MethodGet:
+		v, err = s.getCharge(parts[1])
+	default:
+		err = invalidRequest(http.StatusNotFound, "", "", "Unrecognized request URL (%s: %s).", r.Method, r.URL.Path)
+	}
+	if err != nil {
+		return err.status, marshalError(err)
+	}
+	body, _ := json.MarshalIndent(v, "", "  ")
+	return http.StatusOK, body
+}
+
+func (s *Server) createCustomer(r *http.Request) (interface{}, *apiError) {
+	cus := &customerObject{
+		ID:       newID("cus_", 14),
+		Object:   "customer",
+		Created:  s.now(),
+		Metadata: map[string]string{},
+	}
+	cus.Sources = listObject{Object: "list", Data: []interface{}{}, URL: "/v1/customers/" + cus.ID + "/sources"}
+	if err := s.applyCustomerParams(cus, r); err != nil {
+		return nil, err
+	}
+	s.customers[cus.ID] = cus
+	s.customerIDs = append(s.customerIDs, cus.ID)
+	return cus, nil
+}
+
+func (s *Server) getCustomer(id string) (interface{}, *apiError) {
+	cus, ok := s.customers[id]
+	if !ok {
+		return nil, noSuch(http.StatusNotFound, "customer", id, "id")
+	}
+	if cus.deleted {
+		return deletedObject{ID: id, Object: "customer", Deleted: true}, nil
+	}
+	return cus, nil
+}
+
+func (s *Server) updateCustomer(id string, r *http.Request) (interface{}, *apiError) {
+	cus, ok := s.customers[id]
+	if !ok || cus.deleted {
+		return nil, noSuch(http.StatusNotFound, "customer", id, "id")
+	}
+	// Changes are made to a copy so that a declined card leaves the customer
+	// as it was.
+	updated := *cus
+	updated.Metadata = make(map[string]string)
+	for k, v := range cus.Metadata {
+		updated.Metadata[k] = v
+	}
+	if err := s.applyCustomerParams(&updated, r); err != nil {
+		return nil, err
+	}
+	*cus = updated
+	return cus, nil
+}
+
+func (s *Server) deleteCustomer(id string) (interface{}, *apiError) {
+	cus, ok := s.customers[id]
+	if !ok || cus.deleted {
+		return nil, noSuch(http.StatusNotFound, "customer", id, "id")
+	}
+	cus.deleted = true
+	return deletedObject{ID: id, Object: "customer", Deleted: true}, nil
+}
+
+func (s *Server) listCustomers(r *http.Request) (interface{}, *apiError) {
+	var all []interface{}
+	for i := len(s.customerIDs) - 1; i >= 0; i-- {
+		if cus := s.customers[s.customerIDs[i]]; !cus.deleted {
+			all = append(all, cus)
+		}
+	}
+	return paginate(r, "/v1/customers", all, func(v interface{}) string {
+		return v.(*customerObject).ID
+	})
+}
+
+// applyCustomerParams sets the fields of cus that are in r's form.
+func (s *Server) applyCustomerParams(cus *customerObject, r *http.Request) *apiError {
+	if email := r.Form.Get("email"); email != "" {
+		cus.Email = &email
+	}
+	if desc := r.Form.Get("description"); desc != "" {
+		cus.Description = &desc
+	}
+	setMetadata(cus.Metadata, r)
+	token := r.Form.Get("source")
+	if token == "" {
+		return nil
+	}
+	c, ok := tokens[token]
+	if !ok {
+		return noSuch(http.StatusBadRequest, "token", token, "source")
+	}
+	if c.declineOn != nil && !c.attachOK {
+		return withDocURL(*c.declineOn)
+	}
+	obj := s.newCard(c)
+	obj.Customer = &cus.ID
+	cus.DefaultSource = &obj.ID
+	cus.Sources.Data = []interface{}{obj}
+	return nil
+}
+
+func (s *Server) newCard(c card) *cardObject {
+	obj := &cardObject{
+		ID:       newID("card_", 24),
+		Object:   "card",
+		Brand:    c.brand,
+		Country:  "US",
+		ExpMonth: 12,
+		ExpYear:  time.Now().Year() + 2,
+		Funding:  c.funding,
+		Last4:    c.last4,
+	}
+	s.cards[obj.ID] = c
+	return obj
+}
+
+func (s *Server) createCharge(r *http.Request) (interface{}, *apiError) {
+	amount, err := requiredInt(r, "amount")
+	if err != nil {
+		return nil, err
+	}
+	currency := r.Form.Get("currency")
+	if currency == "" {
+		return nil, invalidRequest(http.StatusBadRequest, "parameter_missing", "currency", "Missing required param: currency.")
+	}
+	if amount < 50 {
+		return nil, invalidRequest(http.StatusBadRequest, "amount_too_small", "amount", "Amount must be at least 50 cents")
+	}
+	chg := &chargeObject{
+		ID:       newID("ch_", 24),
+		Object:   "charge",
+		Amount:   amount,
+		Created:  s.now(),
+		Currency: strings.ToLower(currency),
+		Metadata: map[string]string{},
+	}
+	for param, field := range map[string]**string{
+		"description":          &chg.Description,
+		"receipt_email":        &chg.ReceiptEmail,
+		"statement_descriptor": &chg.StatementDescriptor,
+	} {
+		if v := r.Form.Get(param); v != "" {
+			*field = &v
+		}
+	}
+	if len(r.Form.Get("statement_descriptor")) > 22 {
+		return nil, invalidRequest(http.StatusBadRequest, "", "statement_descriptor", "The statement descriptor must be at most 22 characters.")
+	}
+	setMetadata(chg.Metadata, r)
+	switch cusID, token := r.Form.Get("customer"), r.Form.Get("source"); {
+	case cusID != "":
+		cus, ok := s.customers[cusID]
+		if !ok || cus.deleted {
+			return nil, noSuch(http.StatusBadRequest, "customer", cusID, "customer")
+		}
+		if len(cus.Sources.Data) == 0 {
+			return nil, invalidRequest(http.StatusBadRequest, "missing", "card", "Cannot charge a customer that has no active card")
+		}
+		chg.Customer = &cus.ID
+		chg.Source = cus.Sources.Data[0].(*cardObject)
+	case token != "":
+		c, ok := tokens[token]
+		if !ok {
+			return nil, noSuch(http.StatusBadRequest, "token", token, "source")
+		}
+		chg.Source = s.newCard(c)
+	default:
+		return nil, invalidRequest(http.StatusBadRequest, "parameter_missing", "", "Must provide source or customer.")
+	}
+
+	s.charges[chg.ID] = chg
+	s.chargeIDs = append(s.chargeIDs, chg.ID)
+	if decline := s.cards[chg.Source.ID].declineOn; decline != nil {
+		chg.Status = "failed"
+		chg.FailureCode = &decline.Code
+		chg.FailureMessage = &decline.Message
+		e := withDocURL(*decline)
+		e.Charge = chg.ID
+		return nil, e
+	}
+	chg.Status = "succeeded"
+	chg.Paid = true
+	chg.Captured = true
+	return chg, nil
+}
+
+func (s *Server) getCharge(id string) (interface{}, *apiError) {
+	chg, ok := s.charges[id]
+	if !ok {
+		return nil, noSuch(http.StatusNotFound, "charge", id, "id")
+	}
+	return chg, nil
+}
+
+func (s *Server) listCharges(r *http.Request) (interface{}, *apiError) {
+	cusID := r.Form.Get("customer")
+	var all []interface{}
+	for i := len(s.chargeIDs) - 1; i >= 0; i-- {
+		chg := s.charges[s.chargeIDs[i]]
+		if cusID == "" || (chg.Customer != nil && *chg.Customer == cusID) {
+			all = append(all, chg)
+		}
+	}
+	return paginate(r, "/v1/charges", all, func(v interface{}) string {
+		return v.(*chargeObject).ID
+	})
+}
+
+// paginate returns the page of all requested by r's limit and
+// starting_after parameters, just like Stripe's list endpoints.
+func paginate(r *http.Request, url string, all []interface{}, id func(interface{}) string) (interface{}, *apiError) {
+	limit := 10
+	if r.Form.Get("limit") != "" {
+		n, err := strconv.Atoi(r.Form.Get("limit"))
+		if err != nil || n < 1 || n > 100 {
+			return nil, invalidRequest(http.StatusBadRequest, "parameter_invalid_integer", "limit", "Invalid integer: %s", r.Form.Get("limit"))
+		}
+		limit = n
+	}
+	start := 0
+	if after := r.Form.Get("starting_after"); after != "" {
+		start = -1
+		for i, v := range all {
+			if id(v) == after {
+				start = i + 1
+			}
+		}
+		if start < 0 {
+			return nil, invalidRequest(http.StatusBadRequest, "resource_missing", "starting_after", "No such object: %s", after)
+		}
+	}
+	end := start + limit
+	if end > len(all) {
+		end = len(all)
+	}
+	data := all[start:end]
+	if data == nil {
+		data = []interface{}{}
+	}
+	return listObject{
+		Object:  "list",
+		Data:    data,
+		HasMore: end < len(all),
+		URL:     url,
+	}, nil
+}
+
+// setMetadata applies the metadata[key] parameters in r's form to metadata.
+// Keys with an empty value are removed.
+func setMetadata(metadata map[string]string, r *http.Request) {
+	for param, values := range r.Form {
+		if !strings.HasPrefix(param, "metadata[") || !strings.HasSuffix(param, "]") {
+			continue
+		}
+		key := param[len("metadata[") : len(param)-1]
+		if values[0] == "" {
+			delete(metadata, key)
+			continue
+		}
+		metadata[key] = values[0]
+	}
+}
+
+func requiredInt(r *http.Request, param string) (int, *apiError) {
+	v := r.Form.Get(param)
+	if v == "" {
+		return 0, invalidRequest(http.StatusBadRequest, "parameter_missing", param, "Missing required param: %s.", param)
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, invalidRequest(http.StatusBadRequest, "parameter_invalid_integer", param, "Invalid integer: %s", v)
+	}
+	return n, nil
+}
+
+// withDocURL returns a copy of e with the doc URL Stripe uses for its code.
+func withDocURL(e apiError) *apiError {
+	if e.Code != "" {
+		e.DocURL = "https://stripe.com/docs/error-codes/" + strings.Replace(e.Code, "_", "-", -1)
+	}
+	return &e
+}
+
+func marshalError(e *apiError) []byte {
+	body, _ := json.MarshalIndent(struct {
+		Error *apiError `json:"error"`
+	}{withDocURL(*e)}, "", "  ")
+	return body
+}
+
+func (s *Server) writeError(w http.ResponseWriter, e *apiError) {
+	s.write(w, e.status, marshalError(e))
+}
+
+func (s *Server) write(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Request-Id", newID("req_", 14))
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func (s *Server) now() int64 {
+	if s.Now == nil {
+		return time.Now().Unix()
+	}
+	return s.Now().Unix()
+}
+
+const idChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// newID returns a random ID that looks like one of Stripe's.
+func newID(prefix string, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		x, err := rand.Int(rand.Reader, big.NewInt(int64(len(idChars))))
+		if err != nil {
+			panic(err)
+		}
+		b[i] = idChars[x.Int64()]
+	}
+	return prefix + string(b)
+}
@@ -0,0 +1,262 @@
+package fakestripe_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joncalhoun/twg/stripe"
+	"github.com/joncalhoun/twg/stripe/fakestripe"
+)
+
+func fakeClient(t *testing.T) (*stripe.Client, func()) {
+	ts := httptest.NewServer(fakestripe.New())
+	c := &stripe.Client{
+		Key:     "sk_test_fake",
+		BaseURL: ts.URL + "/v1",
+	}
+	return c, ts.Close
+}
+
+func hasStripeErr(t *testing.T, err error, typee, code string) {
+	se, ok := err.(stripe.Error)
+	if !ok {
+		t.Fatalf("err = %v; want a stripe.Error", err)
+	}
+	if se.Type != typee {
+		t.Errorf("err.Type = %s; want %s", se.Type, typee)
+	}
+	if se.Code != code {
+		t.Errorf("err.Code = %s; want %s", se.Code, code)
+	}
+}
+
+func TestServer_customers(t *testing.T) {
+	c, teardown := fakeClient(t)
+	defer teardown()
+
+	cus, err := c.Customer("tok_amex", "test@testwithgo.com")
+	if err != nil {
+		t.Fatalf("Customer() err = %v; want nil", err)
+	}
+	if !strings.HasPrefix(cus.ID, "cus_") {
+		t.Errorf("ID = %s; want prefix %q", cus.ID, "cus_")
+	}
+	if !strings.HasPrefix(cus.DefaultSource, "card_") {
+		t.Errorf("DefaultSource = %s; want prefix %q", cus.DefaultSource, "card_")
+	}
+	got, err := c.GetCustomer(cus.ID)
+	if err != nil {
+		t.Fatalf("GetCustomer() err = %v; want nil", err)
+	}
+	if !reflect.DeepEqual(got, cus) {
+		t.Errorf("GetCustomer() = %+v; want %+v", got, cus)
+	}
+
+	// A declined card must leave the customer as it was.
+	_, err = c.UpdateCustomer(cus.ID, &stripe.CustomerParams{Source: "tok_chargeDeclinedExpiredCard"})
+	hasStripeErr(t, err, stripe.ErrTypeCardError, "expired_card")
+	updated, err := c.UpdateCustomer(cus.ID, &stripe.CustomerParams{Email: "new@testwithgo.com"})
+	if err != nil {
+		t.Fatalf("UpdateCustomer() err = %v; want nil", err)
+	}
+	if updated.Email != "new@testwithgo.com" || updated.DefaultSource != cus.DefaultSource {
+		t.Errorf("UpdateCustomer() = %+v; want a new email and the original source", updated)
+	}
+
+	if err := c.DeleteCustomer(cus.ID); err != nil {
+		t.Fatalf("DeleteCustomer() err = %v; want nil", err)
+	}
+	got, err = c.GetCustomer(cus.ID)
+	if err != nil {
+		t.Fatalf("GetCustomer() err = %v; want nil", err)
+	}
+	if !got.Deleted {
+		t.Errorf("Deleted = %t; want %t", got.Deleted, true)
+	}
+	err = c.DeleteCustomer(cus.ID)
+	hasStripeErr(t, err, stripe.ErrTypeInvalidRequest, "resource_missing")
+}
+
+func TestServer_customerErrors(t *testing.T) {
+	tests := map[string]struct {
+		token string
+		code  string
+		typee string
+	}{
+		"invalid token":      {"tok_alsdkjfa", "resource_missing", stripe.ErrTypeInvalidRequest},
+		"expired card":       {"tok_chargeDeclinedExpiredCard", "expired_card", stripe.ErrTypeCardError},
+		"incorrect cvc":      {"tok_chargeDeclinedIncorrectCvc", "incorrect_cvc", stripe.ErrTypeCardError},
+		"insufficient funds": {"tok_chargeDeclinedInsufficientFunds", "card_declined", stripe.ErrTypeCardError},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, teardown := fakeClient(t)
+			defer teardown()
+			_, err := c.Customer(tc.token, "test@testwithgo.com")
+			hasStripeErr(t, err, tc.typee, tc.code)
+		})
+	}
+}
+
+func TestServer_listCustomers(t *testing.T) {
+	c, teardown := fakeClient(t)
+	defer teardown()
+	var want []string
+	for i := 0; i < 5; i++ {
+		cus, err := c.Customer("tok_visa", "test@testwithgo.com")
+		if err != nil {
+			t.Fatalf("Customer() err = %v; want nil", err)
+		}
+		want = append([]string{cus.ID}, want...)
+	}
+	if err := c.DeleteCustomer(want[2]); err != nil {
+		t.Fatalf("DeleteCustomer() err = %v; want nil", err)
+	}
+	want = append(want[:2], want[3:]...)
+
+	it := c.ListCustomers(&stripe.ListParams{Limit: 2})
+	var got []string
+	for it.Next() {
+		got = append(got, it.Customer().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v; want nil", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("customer IDs = %v; want %v", got, want)
+	}
+}
+
+func TestServer_charges(t *testing.T) {
+	tests := map[string]struct {
+		token  string
+		amount int
+		status string
+		code   string
+	}{
+		"succeeds":         {"tok_visa", 1234, "succeeded", ""},
+		"amount too small": {"tok_visa", 49, "", "amount_too_small"},
+		"charge fails":     {"tok_chargeCustomerFail", 1234, "", "card_declined"},
+		"prepaid succeeds": {"tok_mastercard_prepaid", 50, "succeeded", ""},
+		"missing customer": {"", 1234, "", "resource_missing"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, teardown := fakeClient(t)
+			defer teardown()
+			cusID := "cus_missing"
+			if tc.token != "" {
+				cus, err := c.Customer(tc.token, "test@testwithgo.com")
+				if err != nil {
+					t.Fatalf("Customer() err = %v; want nil", err)
+				}
+				cusID = cus.ID
+			}
+			chg, err := c.Charge(cusID, tc.amount)
+			if tc.code != "" {
+				se, ok := err.(stripe.Error)
+				if !ok || se.Code != tc.code {
+					t.Fatalf("Charge() err = %v; want code %s", err, tc.code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Charge() err = %v; want nil", err)
+			}
+			if chg.Amount != tc.amount || chg.Status != tc.status || !chg.Paid {
+				t.Errorf("Charge() = %+v; want a paid charge of %d", chg, tc.amount)
+			}
+		})
+	}
+}
+
+func TestServer_idempotencyKey(t *testing.T) {
+	c, teardown := fakeClient(t)
+	defer teardown()
+	first, err := c.Customer("tok_visa", "test@testwithgo.com", stripe.IdempotencyKey("order-123"))
+	if err != nil {
+		t.Fatalf("Customer() err = %v; want nil", err)
+	}
+	second, err := c.Customer("tok_amex", "other@testwithgo.com", stripe.IdempotencyKey("order-123"))
+	if err != nil {
+		t.Fatalf("Customer() err = %v; want nil", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Customer() = %+v; want the first response %+v to be replayed", second, first)
+	}
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	tests := map[string]struct {
+		method, path string
+		key          string
+		wantStatus   int
+	}{
+		"no key":       {http.MethodGet, "/v1/customers", "", http.StatusUnauthorized},
+		"unknown path": {http.MethodGet, "/v1/widgets", "sk_test_fake", http.StatusNotFound},
+		"no prefix":    {http.MethodGet, "/customers", "sk_test_fake", http.StatusOK},
+		"missing":      {http.MethodGet, "/v1/charges/ch_missing", "sk_test_fake", http.StatusNotFound},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.key != "" {
+				r.SetBasicAuth(tc.key, "")
+			}
+			w := httptest.NewRecorder()
+			fakestripe.New().ServeHTTP(w, r)
+			if w.Code != tc.wantStatus {
+				t.Errorf("status = %d; want %d; body = %s", w.Code, tc.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestServer_metadata(t *testing.T) {
+	c, teardown := fakeClient(t)
+	defer teardown()
+	cus, err := c.CreateCustomer(&stripe.CustomerParams{
+		Source:      "tok_visa",
+		Description: "Swag store customer",
+		Metadata:    map[string]string{"user_id": "123", "plan": "gold"},
+	})
+	if err != nil {
+		t.Fatalf("CreateCustomer() err = %v; want nil", err)
+	}
+	cus, err = c.UpdateCustomer(cus.ID, &stripe.CustomerParams{
+		Metadata: map[string]string{"plan": "", "ref": "twitter"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateCustomer() err = %v; want nil", err)
+	}
+	if want := map[string]string{"user_id": "123", "ref": "twitter"}; !reflect.DeepEqual(cus.Metadata, want) {
+		t.Errorf("Metadata = %v; want %v", cus.Metadata, want)
+	}
+	if cus.Description != "Swag store customer" {
+		t.Errorf("Description = %s; want %s", cus.Description, "Swag store customer")
+	}
+
+	chg, err := c.CreateCharge(&stripe.ChargeParams{
+		Customer:            cus.ID,
+		Amount:              stripe.Money{Amount: 1200, Currency: stripe.JPY},
+		Description:         "Swag order 123",
+		StatementDescriptor: "TWG SWAG",
+		ReceiptEmail:        "receipts@testwithgo.com",
+		Metadata:            map[string]string{"order_id": "123"},
+	})
+	if err != nil {
+		t.Fatalf("CreateCharge() err = %v; want nil", err)
+	}
+	if chg.Description != "Swag order 123" || chg.StatementDescriptor != "TWG SWAG" || chg.ReceiptEmail != "receipts@testwithgo.com" {
+		t.Errorf("CreateCharge() = %+v; want the description, statement descriptor and receipt email set", chg)
+	}
+	if got := chg.Money().String(); got != "¥1,200" {
+		t.Errorf("Money() = %s; want %s", got, "¥1,200")
+	}
+	if !reflect.DeepEqual(chg.Metadata, map[string]string{"order_id": "123"}) {
+		t.Errorf("Metadata = %v; want order_id", chg.Metadata)
+	}
+}
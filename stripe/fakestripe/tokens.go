@@ -0,0 +1,70 @@
+package fakestripe
+
+// card is the card a test token stands for.
+type card struct {
+	brand, funding, last4 string
+	// declineOn is the error returned when the card is attached to a
+	// customer, or if attachOK is true, when it is charged.
+	declineOn *apiError
+	attachOK  bool
+}
+
+// Errors returned for the test tokens that Stripe declines.
+var (
+	errExpiredCard = &apiError{
+		status:  402,
+		Type:    "card_error",
+		Code:    "expired_card",
+		Message: "Your card has expired.",
+		Param:   "exp_month",
+	}
+	errIncorrectCVC = &apiError{
+		status:  402,
+		Type:    "card_error",
+		Code:    "incorrect_cvc",
+		Message: "Your card's security code is incorrect.",
+		Param:   "cvc",
+	}
+	errInsufficientFunds = &apiError{
+		status:      402,
+		Type:        "card_error",
+		Code:        "card_declined",
+		DeclineCode: "insufficient_funds",
+		Message:     "Your card has insufficient funds.",
+	}
+	errGenericDecline = &apiError{
+		status:      402,
+		Type:        "card_error",
+		Code:        "card_declined",
+		DeclineCode: "generic_decline",
+		Message:     "Your card was declined.",
+	}
+	errProcessingError = &apiError{
+		status:  402,
+		Type:    "card_error",
+		Code:    "processing_error",
+		Message: "An error occurred while processing your card. Try again in a little bit.",
+	}
+)
+
+// tokens are the well known test tokens from
+// https://stripe.com/docs/testing, mapped to the cards they stand for.
+var tokens = map[string]card{
+	"tok_visa":               {brand: "Visa", funding: "credit", last4: "4242"},
+	"tok_visa_debit":         {brand: "Visa", funding: "debit", last4: "5556"},
+	"tok_mastercard":         {brand: "MasterCard", funding: "credit", last4: "4444"},
+	"tok_mastercard_debit":   {brand: "MasterCard", funding: "debit", last4: "8210"},
+	"tok_mastercard_prepaid": {brand: "MasterCard", funding: "prepaid", last4: "5100"},
+	"tok_amex":               {brand: "American Express", funding: "credit", last4: "8431"},
+	"tok_discover":           {brand: "Discover", funding: "credit", last4: "1117"},
+	"tok_diners":             {brand: "Diners Club", funding: "credit", last4: "0004"},
+	"tok_jcb":                {brand: "JCB", funding: "credit", last4: "0505"},
+	"tok_unionpay":           {brand: "UnionPay", funding: "credit", last4: "0005"},
+
+	"tok_chargeDeclined":                  {brand: "Visa", funding: "credit", last4: "0002", declineOn: errGenericDecline},
+	"tok_chargeDeclinedInsufficientFunds": {brand: "Visa", funding: "credit", last4: "9995", declineOn: errInsufficientFunds},
+	"tok_chargeDeclinedExpiredCard":       {brand: "Visa", funding: "credit", last4: "0069", declineOn: errExpiredCard},
+	"tok_chargeDeclinedIncorrectCvc":      {brand: "Visa", funding: "credit", last4: "0127", declineOn: errIncorrectCVC},
+	"tok_chargeDeclinedProcessingError":   {brand: "Visa", funding: "credit", last4: "0119", declineOn: errProcessingError},
+	"tok_chargeCustomerFail":              {brand: "Visa", funding: "credit", last4: "0341", declineOn: errGenericDecline, attachOK: true},
+}
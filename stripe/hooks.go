@@ -0,0 +1,102 @@
+package stripe
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RedactedKey replaces the API key in the headers given to a Hook.
+const RedactedKey = "[redacted]"
+
+// Hook observes the requests a Client makes, eg to log them or record
+// metrics. AfterRequest is called once for every attempt, including those
+// that are retried, after the response headers have been received or the
+// attempt has failed. Hooks are called synchronously, so slow hooks slow
+// down the Client.
+//
+//	c.Hooks = append(c.Hooks, stripe.HookFunc(func(ctx context.Context, e *stripe.RequestEvent) {
+//		log.Printf("stripe: %s %s %d %s in %v", e.Method, e.Path, e.StatusCode, e.RequestID, e.Duration)
+//	}))
+type Hook interface {
+	AfterRequest(ctx context.Context, e *RequestEvent)
+}
+
+// HookFunc adapts a function to a Hook.
+type HookFunc func(ctx context.Context, e *RequestEvent)
+
+func (fn HookFunc) AfterRequest(ctx context.Context, e *RequestEvent) {
+	fn(ctx, e)
+}
+
+// RequestEvent describes a single attempt at a request. Every hook is given
+// its own copy, so hooks may keep or change it without affecting the Client
+// or any other hook.
+type RequestEvent struct {
+	Method string
+	// Path is the path of the request relative to the Client's BaseURL, eg
+	// "/customers".
+	Path string
+	// Params are the parameters sent in the query string or body.
+	Params url.Values
+	// Header holds the request's headers. The API key in the Authorization
+	// header is replaced with RedactedKey.
+	Header http.Header
+	// Attempt is 1 for the first attempt, 2 for the first retry and so on.
+	Attempt int
+
+	// StatusCode, ResponseHeader and RequestID come from the response. They
+	// are empty if Err is set.
+	StatusCode     int
+	ResponseHeader http.Header
+	// RequestID is the response's Request-Id header, which Stripe support
+	// can use to find the request.
+	RequestID string
+	// Err is set if no response was received, eg because of a network
+	// error. Responses with an error status don't set Err.
+	Err error
+	// Duration is how long the attempt took.
+	Duration time.Duration
+}
+
+// afterRequest calls the Client's hooks for an attempt at req that was sent
+// with params and returned res and err after d.
+func (c *Client) afterRequest(ctx context.Context, path string, params url.Values, req *http.Request, attempt int, res *http.Response, err error, d time.Duration) {
+	if len(c.Hooks) == 0 {
+		return
+	}
+	header := req.Header.Clone()
+	if header.Get("Authorization") != "" {
+		header.Set("Authorization", RedactedKey)
+	}
+	e := RequestEvent{
+		Method:   req.Method,
+		Path:     path,
+		Params:   params,
+		Header:   header,
+		Attempt:  attempt,
+		Err:      err,
+		Duration: d,
+	}
+	if res != nil {
+		e.StatusCode = res.StatusCode
+		e.ResponseHeader = res.Header
+		e.RequestID = res.Header.Get("Request-Id")
+	}
+	for _, h := range c.Hooks {
+		h.AfterRequest(ctx, e.clone())
+	}
+}
+
+// clone returns a copy of e that shares none of its maps or slices.
+func (e *RequestEvent) clone() *RequestEvent {
+	cp := *e
+	cp.Params = url.Values{}
+	for k, vs := range e.Params {
+		cp.Params[k] = append([]string(nil), vs...)
+	}
+	cp.Header = e.Header.Clone()
+	cp.ResponseHeader = e.ResponseHeader.Clone()
+	return &cp
+}
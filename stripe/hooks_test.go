@@ -0,0 +1,141 @@
+package stripe_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+func TestClient_Hooks(t *testing.T) {
+	const key = "sk_test_secret"
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Request-Id", fmt.Sprintf("req_%d", calls))
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error": {"type": "api_error", "message": "Try again."}}`)
+			return
+		}
+		w.WriteHeader(http.StatusPaymentRequired)
+		fmt.Fprint(w, `{"error": {"type": "card_error", "code": "card_declined", "message": "Your card was declined."}}`)
+	}))
+	defer server.Close()
+
+	type ctxKey struct{}
+	var events []*stripe.RequestEvent
+	c := stripe.Client{
+		Key:     key,
+		BaseURL: server.URL,
+		Retry:   &stripe.RetryPolicy{Sleep: func(time.Duration) {}},
+		Hooks: []stripe.Hook{stripe.HookFunc(func(ctx context.Context, e *stripe.RequestEvent) {
+			if ctx.Value(ctxKey{}) != "checkout" {
+				t.Errorf("hook ctx is missing the caller's value")
+			}
+			events = append(events, e)
+		})},
+	}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "checkout")
+	_, err := c.ChargeContext(ctx, "cus_123", 1234)
+	var se stripe.Error
+	if !errors.As(err, &se) || se.RequestID != "req_2" {
+		t.Errorf("err = %v with RequestID %q; want a stripe.Error with RequestID %q", err, se.RequestID, "req_2")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("events = %d; want 2", len(events))
+	}
+	wantParams := url.Values{"customer": {"cus_123"}, "amount": {"1234"}, "currency": {"usd"}}
+	for i, e := range events {
+		if e.Method != http.MethodPost || e.Path != "/charges" {
+			t.Errorf("event %d request = %s %s; want POST /charges", i, e.Method, e.Path)
+		}
+		if !reflect.DeepEqual(e.Params, wantParams) {
+			t.Errorf("event %d Params = %v; want %v", i, e.Params, wantParams)
+		}
+		if e.Attempt != i+1 {
+			t.Errorf("event %d Attempt = %d; want %d", i, e.Attempt, i+1)
+		}
+		if got := e.Header.Get("Authorization"); got != stripe.RedactedKey {
+			t.Errorf("event %d Authorization = %q; want %q", i, got, stripe.RedactedKey)
+		}
+		if e.Header.Get(stripe.IdempotencyKeyHeader) == "" {
+			t.Errorf("event %d has no idempotency key; want the request's headers", i)
+		}
+		if e.RequestID != fmt.Sprintf("req_%d", i+1) {
+			t.Errorf("event %d RequestID = %s; want req_%d", i, e.RequestID, i+1)
+		}
+		if e.Duration <= 0 {
+			t.Errorf("event %d Duration = %v; want > 0", i, e.Duration)
+		}
+		if e.Err != nil {
+			t.Errorf("event %d Err = %v; want nil", i, e.Err)
+		}
+		if strings.Contains(fmt.Sprintf("%+v", *e), key) {
+			t.Errorf("event %d = %+v; want the key to be redacted", i, *e)
+		}
+	}
+	if events[0].StatusCode != http.StatusServiceUnavailable || events[1].StatusCode != http.StatusPaymentRequired {
+		t.Errorf("StatusCodes = %d, %d; want %d, %d", events[0].StatusCode, events[1].StatusCode, http.StatusServiceUnavailable, http.StatusPaymentRequired)
+	}
+
+	server.Close()
+	events = nil
+	c.Retry = nil
+	c.GetCustomerContext(ctx, "cus_123")
+	if len(events) != 1 || events[0].Err == nil || events[0].StatusCode != 0 {
+		t.Errorf("events = %+v; want one event with an Err and no StatusCode", events)
+	}
+}
+
+func TestClient_Hooks_copies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Request-Id", "req_1")
+		fmt.Fprint(w, `{"id": "cus_123"}`)
+	}))
+	defer server.Close()
+
+	var second *stripe.RequestEvent
+	c := stripe.Client{
+		Key:     "sk_test_secret",
+		BaseURL: server.URL,
+		Hooks: []stripe.Hook{
+			stripe.HookFunc(func(ctx context.Context, e *stripe.RequestEvent) {
+				e.Method = "changed"
+				e.Params.Set("email", "changed")
+				e.Header.Set("Authorization", "changed")
+				e.ResponseHeader.Set("Request-Id", "changed")
+			}),
+			stripe.HookFunc(func(ctx context.Context, e *stripe.RequestEvent) {
+				second = e
+			}),
+		},
+	}
+	if _, err := c.Customer(tokenAmex, "test@testwithgo.com"); err != nil {
+		t.Fatalf("Customer() err = %v; want nil", err)
+	}
+	if second == nil {
+		t.Fatalf("second hook was not called")
+	}
+	if second.Method != http.MethodPost {
+		t.Errorf("Method = %s; want %s", second.Method, http.MethodPost)
+	}
+	if got := second.Params.Get("email"); got != "test@testwithgo.com" {
+		t.Errorf("Params email = %q; want %q", got, "test@testwithgo.com")
+	}
+	if got := second.Header.Get("Authorization"); got != stripe.RedactedKey {
+		t.Errorf("Authorization = %q; want %q", got, stripe.RedactedKey)
+	}
+	if got := second.ResponseHeader.Get("Request-Id"); got != "req_1" {
+		t.Errorf("ResponseHeader Request-Id = %q; want %q", got, "req_1")
+	}
+}
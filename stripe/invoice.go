@@ -0,0 +1,127 @@
+package stripe
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// The statuses an Invoice can have.
+const (
+	InvoiceDraft         = "draft"
+	InvoiceOpen          = "open"
+	InvoicePaid          = "paid"
+	InvoiceUncollectible = "uncollectible"
+	InvoiceVoid          = "void"
+)
+
+// Invoice is a bill for a customer, such as the one a Subscription creates
+// for each billing period. Amounts are in the smallest unit of Currency.
+//
+// Requests for invoices are sent with SubscriptionsVersion, like those for
+// the subscriptions that create them.
+type Invoice struct {
+	ID               string            `json:"id"`
+	AmountDue        int               `json:"amount_due"`
+	AmountPaid       int               `json:"amount_paid"`
+	AmountRemaining  int               `json:"amount_remaining"`
+	Created          int64             `json:"created"`
+	Currency         Currency          `json:"currency"`
+	Customer         string            `json:"customer"`
+	HostedInvoiceURL string            `json:"hosted_invoice_url"`
+	Lines            InvoiceLineList   `json:"lines"`
+	Livemode         bool              `json:"livemode"`
+	Metadata         map[string]string `json:"metadata"`
+	Paid             bool              `json:"paid"`
+	Status           string            `json:"status"`
+	Subscription     string            `json:"subscription"`
+}
+
+// InvoiceLineList is the list of lines on an Invoice. Stripe includes up to
+// 10 lines, and HasMore is true if there are more than that.
+type InvoiceLineList struct {
+	Data    []InvoiceLine `json:"data"`
+	HasMore bool          `json:"has_more"`
+}
+
+// InvoiceLine is a single line of an Invoice, such as a subscription item.
+type InvoiceLine struct {
+	ID          string   `json:"id"`
+	Amount      int      `json:"amount"`
+	Currency    Currency `json:"currency"`
+	Description string   `json:"description"`
+	Price       *Price   `json:"price"`
+	Quantity    int      `json:"quantity"`
+}
+
+// GetInvoice retrieves the invoice with the given ID.
+func (c *Client) GetInvoice(id string, opts ...RequestOption) (*Invoice, error) {
+	return c.GetInvoiceContext(context.Background(), id, opts...)
+}
+
+// GetInvoiceContext is like GetInvoice, but ctx is used to cancel its
+// requests.
+func (c *Client) GetInvoiceContext(ctx context.Context, id string, opts ...RequestOption) (*Invoice, error) {
+	var inv Invoice
+	if err := c.call(ctx, http.MethodGet, "/invoices/"+url.PathEscape(id), nil, &inv, withVersion(SubscriptionsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// PayInvoice attempts to pay the open invoice with the given ID using the
+// customer's default payment source, which is useful once a customer whose
+// payment failed has updated their card.
+func (c *Client) PayInvoice(id string, opts ...RequestOption) (*Invoice, error) {
+	return c.PayInvoiceContext(context.Background(), id, opts...)
+}
+
+// PayInvoiceContext is like PayInvoice, but ctx is used to cancel its
+// requests.
+func (c *Client) PayInvoiceContext(ctx context.Context, id string, opts ...RequestOption) (*Invoice, error) {
+	var inv Invoice
+	if err := c.call(ctx, http.MethodPost, "/invoices/"+url.PathEscape(id)+"/pay", nil, &inv, withVersion(SubscriptionsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// ListInvoices returns an iterator over the invoices of the customer with
+// the given ID, newest first. If customerID is empty every invoice is
+// listed.
+func (c *Client) ListInvoices(customerID string, params *ListParams, opts ...RequestOption) *InvoiceIter {
+	return c.ListInvoicesContext(context.Background(), customerID, params, opts...)
+}
+
+// ListInvoicesContext is like ListInvoices, but ctx is used to cancel its
+// requests.
+func (c *Client) ListInvoicesContext(ctx context.Context, customerID string, params *ListParams, opts ...RequestOption) *InvoiceIter {
+	it := newIter(ctx, c, "/invoices", params, withVersion(SubscriptionsVersion, opts))
+	if customerID != "" {
+		it.params.Set("customer", customerID)
+	}
+	return &InvoiceIter{iter: it}
+}
+
+// InvoiceIter iterates over a list of invoices. It is used just like a
+// CustomerIter.
+type InvoiceIter struct {
+	*iter
+	inv *Invoice
+}
+
+// Next advances to the next invoice, fetching the next page of invoices if
+// needed.
+func (it *InvoiceIter) Next() bool {
+	var inv Invoice
+	if !it.next(&inv) {
+		return false
+	}
+	it.inv = &inv
+	return true
+}
+
+// Invoice returns the current invoice.
+func (it *InvoiceIter) Invoice() *Invoice {
+	return it.inv
+}
@@ -0,0 +1,87 @@
+package stripe_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+func TestClient_GetInvoice(t *testing.T) {
+	t.Run("subscription invoice", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		cus, sub := subscribe(t, c, tokenVisa, 2)
+		inv, err := c.GetInvoice(sub.LatestInvoice)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if inv.Status != stripe.InvoicePaid || !inv.Paid {
+			t.Errorf("Status = %s, Paid = %t; want %s, true", inv.Status, inv.Paid, stripe.InvoicePaid)
+		}
+		if inv.Customer != cus.ID || inv.Subscription != sub.ID {
+			t.Errorf("Customer, Subscription = %s, %s; want %s, %s", inv.Customer, inv.Subscription, cus.ID, sub.ID)
+		}
+		if inv.AmountPaid != 3000 {
+			t.Errorf("AmountPaid = %d; want %d", inv.AmountPaid, 3000)
+		}
+		if len(inv.Lines.Data) != 1 || inv.Lines.Data[0].Quantity != 2 {
+			t.Errorf("Lines = %+v; want one line with a quantity of 2", inv.Lines)
+		}
+	})
+	t.Run("missing invoice", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		_, err := c.GetInvoice("in_missing")
+		hasStripeErr(t, err, stripe.ErrTypeInvalidRequest)
+	})
+}
+
+func TestClient_ListInvoices(t *testing.T) {
+	t.Run("invoices for a customer", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		cus, sub := subscribe(t, c, tokenVisa, 1)
+		it := c.ListInvoices(cus.ID, nil)
+		var got []string
+		for it.Next() {
+			got = append(got, it.Invoice().ID)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("Err() = %v; want nil", err)
+		}
+		if want := []string{sub.LatestInvoice}; !reflect.DeepEqual(got, want) {
+			t.Errorf("invoice IDs = %v; want %v", got, want)
+		}
+	})
+}
+
+func TestClient_PayInvoice(t *testing.T) {
+	t.Run("after updating the card", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		cus, sub := subscribe(t, c, tokenChargeCustomerFail, 1)
+		_, err := c.PayInvoice(sub.LatestInvoice)
+		if !errors.Is(err, stripe.ErrCardDeclined) {
+			t.Fatalf("err = %v; want %v", err, stripe.ErrCardDeclined)
+		}
+		if _, err := c.UpdateCustomer(cus.ID, &stripe.CustomerParams{Source: tokenVisa}); err != nil {
+			t.Fatalf("UpdateCustomer() err = %v; want nil", err)
+		}
+		inv, err := c.PayInvoice(sub.LatestInvoice)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if inv.Status != stripe.InvoicePaid || inv.AmountPaid != 1500 {
+			t.Errorf("Status = %s, AmountPaid = %d; want %s, %d", inv.Status, inv.AmountPaid, stripe.InvoicePaid, 1500)
+		}
+	})
+	t.Run("already paid", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		_, sub := subscribe(t, c, tokenVisa, 1)
+		_, err := c.PayInvoice(sub.LatestInvoice)
+		hasStripeErr(t, err, stripe.ErrTypeInvalidRequest)
+	})
+}
@@ -0,0 +1,116 @@
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListParams are the parameters accepted by every List method.
+type ListParams struct {
+	// Limit is the number of objects fetched in each page, between 1 and 100.
+	// Stripe uses 10 by default.
+	Limit int
+	// StartingAfter is the ID of an object to start the list after, which
+	// allows resuming a list that was only partly iterated over.
+	StartingAfter string
+}
+
+func (p *ListParams) values() url.Values {
+	v := url.Values{}
+	if p == nil {
+		return v
+	}
+	if p.Limit > 0 {
+		v.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.StartingAfter != "" {
+		v.Set("starting_after", p.StartingAfter)
+	}
+	return v
+}
+
+// list is a single page of objects returned by a list endpoint.
+type list struct {
+	Data    []json.RawMessage `json:"data"`
+	HasMore bool              `json:"has_more"`
+}
+
+// iter pages through a list endpoint using Stripe's cursor pagination. Each
+// page is requested with starting_after set to the ID of the last object in
+// the previous page, until a page says there are no more. It is embedded in
+// the iterators for each type of object, which decode the objects.
+type iter struct {
+	ctx     context.Context
+	c       *Client
+	path    string
+	params  url.Values
+	opts    []RequestOption
+	page    []json.RawMessage
+	hasMore bool
+	err     error
+}
+
+func newIter(ctx context.Context, c *Client, path string, params *ListParams, opts []RequestOption) *iter {
+	return &iter{
+		ctx:     ctx,
+		c:       c,
+		path:    path,
+		params:  params.values(),
+		opts:    opts,
+		hasMore: true,
+	}
+}
+
+// next decodes the next object into v, fetching a new page first if the
+// current one has been used up.
+func (it *iter) next(v interface{}) bool {
+	if it.err != nil {
+		return false
+	}
+	if len(it.page) == 0 {
+		if !it.hasMore {
+			return false
+		}
+		it.fetch()
+		if it.err != nil || len(it.page) == 0 {
+			return false
+		}
+	}
+	obj := it.page[0]
+	it.page = it.page[1:]
+	if err := json.Unmarshal(obj, v); err != nil {
+		it.err = err
+		return false
+	}
+	return true
+}
+
+func (it *iter) fetch() {
+	var l list
+	if err := it.c.call(it.ctx, http.MethodGet, it.path, it.params, &l, it.opts); err != nil {
+		it.err = err
+		return
+	}
+	it.page = l.Data
+	it.hasMore = l.HasMore
+	if len(l.Data) == 0 {
+		it.hasMore = false
+		return
+	}
+	var last struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(l.Data[len(l.Data)-1], &last); err != nil {
+		it.err = err
+		return
+	}
+	it.params.Set("starting_after", last.ID)
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *iter) Err() error {
+	return it.err
+}
@@ -0,0 +1,166 @@
+package stripe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Currency is a three letter ISO currency code. Stripe uses lower case codes,
+// but upper case ones are accepted everywhere a Currency is.
+type Currency string
+
+// Some commonly used currencies. Any other ISO code can be used by
+// converting it, eg Currency("chf").
+const (
+	USD Currency = "usd"
+	EUR Currency = "eur"
+	GBP Currency = "gbp"
+	CAD Currency = "cad"
+	AUD Currency = "aud"
+	JPY Currency = "jpy"
+	KRW Currency = "krw"
+	KWD Currency = "kwd"
+)
+
+// zeroDecimal are the currencies that Stripe charges in whole units, so an
+// amount of 500 JPY is ¥500 rather than ¥5.00.
+var zeroDecimal = map[Currency]bool{
+	"bif": true, "clp": true, "djf": true, "gnf": true,
+	"jpy": true, "kmf": true, "krw": true, "mga": true,
+	"pyg": true, "rwf": true, "ugx": true, "vnd": true,
+	"vuv": true, "xaf": true, "xof": true, "xpf": true,
+}
+
+// threeDecimal are the currencies with three decimal places.
+var threeDecimal = map[Currency]bool{
+	"bhd": true, "jod": true, "kwd": true, "omr": true, "tnd": true,
+}
+
+// symbols are used to format the currencies that have a well known symbol.
+var symbols = map[Currency]string{
+	"usd": "$",
+	"eur": "€",
+	"gbp": "£",
+	"jpy": "¥",
+	"krw": "₩",
+}
+
+func (c Currency) code() Currency {
+	return Currency(strings.ToLower(string(c)))
+}
+
+// Decimals returns the number of decimal places c has, which is the number
+// of digits of an amount in the smallest unit of c that come after the
+// decimal point.
+func (c Currency) Decimals() int {
+	switch {
+	case zeroDecimal[c.code()]:
+		return 0
+	case threeDecimal[c.code()]:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// Money is an amount of a currency. Amount is in the currency's smallest
+// unit, which is what Stripe expects, so 1234 USD is $12.34, 1234 JPY is
+// ¥1,234 and 1234 KWD is 1.234 KWD.
+type Money struct {
+	Amount   int
+	Currency Currency
+}
+
+// ParseMoney parses s, a decimal amount of cur such as "12.34", into Money.
+// It is an error for s to have more decimal places than cur.
+func ParseMoney(s string, cur Currency) (Money, error) {
+	invalid := func() (Money, error) {
+		return Money{}, fmt.Errorf("stripe: invalid %s amount %q", strings.ToUpper(string(cur)), s)
+	}
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	negative := strings.HasPrefix(whole, "-")
+	whole = strings.TrimPrefix(whole, "-")
+	decimals := cur.Decimals()
+	if whole == "" || !isDigits(whole) || !isDigits(frac) || len(frac) > decimals {
+		return invalid()
+	}
+	frac += strings.Repeat("0", decimals-len(frac))
+	n, err := strconv.Atoi(whole + frac)
+	if err != nil {
+		return invalid()
+	}
+	if negative {
+		n = -n
+	}
+	return Money{Amount: n, Currency: cur}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal returns m's amount as a decimal number in the currency's main
+// unit, eg "12.34" for 1234 USD or "1234" for 1234 JPY.
+func (m Money) Decimal() string {
+	s, negative := m.digits()
+	if negative {
+		return "-" + s
+	}
+	return s
+}
+
+// digits returns the absolute amount of m formatted as a decimal, and
+// whether it was negative.
+func (m Money) digits() (string, bool) {
+	n := m.Amount
+	negative := n < 0
+	if negative {
+		n = -n
+	}
+	s := strconv.Itoa(n)
+	decimals := m.Currency.Decimals()
+	if decimals == 0 {
+		return s, negative
+	}
+	if len(s) <= decimals {
+		s = strings.Repeat("0", decimals-len(s)+1) + s
+	}
+	return s[:len(s)-decimals] + "." + s[len(s)-decimals:], negative
+}
+
+// String formats m for display, with thousands separated by commas. Known
+// currencies are written with their symbol, eg "€1,234.50", while others
+// are followed by their code, eg "1,234.500 KWD".
+func (m Money) String() string {
+	s, negative := m.digits()
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i:]
+	}
+	var b strings.Builder
+	if negative {
+		b.WriteByte('-')
+	}
+	symbol, ok := symbols[m.Currency.code()]
+	b.WriteString(symbol)
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(frac)
+	if !ok {
+		b.WriteString(" " + strings.ToUpper(string(m.Currency)))
+	}
+	return b.String()
+}
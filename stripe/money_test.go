@@ -0,0 +1,89 @@
+package stripe_test
+
+import (
+	"testing"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+func TestCurrency_Decimals(t *testing.T) {
+	tests := map[stripe.Currency]int{
+		stripe.USD: 2,
+		stripe.EUR: 2,
+		stripe.JPY: 0,
+		stripe.KRW: 0,
+		stripe.KWD: 3,
+		"VND":      0,
+		"chf":      2,
+	}
+	for cur, want := range tests {
+		if got := cur.Decimals(); got != want {
+			t.Errorf("%s.Decimals() = %d; want %d", cur, got, want)
+		}
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		money       stripe.Money
+		wantString  string
+		wantDecimal string
+	}{
+		{stripe.Money{Amount: 1234, Currency: stripe.USD}, "$12.34", "12.34"},
+		{stripe.Money{Amount: 5, Currency: stripe.USD}, "$0.05", "0.05"},
+		{stripe.Money{Amount: 0, Currency: stripe.EUR}, "€0.00", "0.00"},
+		{stripe.Money{Amount: 123456789, Currency: stripe.GBP}, "£1,234,567.89", "1234567.89"},
+		{stripe.Money{Amount: -2050, Currency: stripe.EUR}, "-€20.50", "-20.50"},
+		{stripe.Money{Amount: 1200, Currency: stripe.JPY}, "¥1,200", "1200"},
+		{stripe.Money{Amount: 50000, Currency: stripe.KRW}, "₩50,000", "50000"},
+		{stripe.Money{Amount: 1234560, Currency: stripe.KWD}, "1,234.560 KWD", "1234.560"},
+		{stripe.Money{Amount: 999, Currency: "chf"}, "9.99 CHF", "9.99"},
+	}
+	for _, tc := range tests {
+		if got := tc.money.String(); got != tc.wantString {
+			t.Errorf("%+v.String() = %s; want %s", tc.money, got, tc.wantString)
+		}
+		if got := tc.money.Decimal(); got != tc.wantDecimal {
+			t.Errorf("%+v.Decimal() = %s; want %s", tc.money, got, tc.wantDecimal)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		s       string
+		cur     stripe.Currency
+		want    int
+		wantErr bool
+	}{
+		{s: "12.34", cur: stripe.USD, want: 1234},
+		{s: "12.3", cur: stripe.EUR, want: 1230},
+		{s: "12", cur: stripe.GBP, want: 1200},
+		{s: "-0.5", cur: stripe.USD, want: -50},
+		{s: "1200", cur: stripe.JPY, want: 1200},
+		{s: "1.234", cur: stripe.KWD, want: 1234},
+		{s: "12.345", cur: stripe.USD, wantErr: true},
+		{s: "12.5", cur: stripe.JPY, wantErr: true},
+		{s: "", cur: stripe.USD, wantErr: true},
+		{s: ".50", cur: stripe.USD, wantErr: true},
+		{s: "1,200", cur: stripe.USD, wantErr: true},
+		{s: "+5", cur: stripe.USD, wantErr: true},
+		{s: "5.-1", cur: stripe.USD, wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := stripe.ParseMoney(tc.s, tc.cur)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q, %s) err = nil; want an error", tc.s, tc.cur)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %s) err = %v; want nil", tc.s, tc.cur, err)
+			continue
+		}
+		if want := (stripe.Money{Amount: tc.want, Currency: tc.cur}); got != want {
+			t.Errorf("ParseMoney(%q, %s) = %+v; want %+v", tc.s, tc.cur, got, want)
+		}
+	}
+}
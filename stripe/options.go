@@ -0,0 +1,69 @@
+package stripe
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// IdempotencyKeyHeader is the header used to send idempotency keys.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RequestOption configures a single call to the Stripe API. Every Client
+// method accepts them after its other arguments.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	idempotencyKey string
+	version        string
+}
+
+func newRequestOptions(opts []RequestOption) requestOptions {
+	var o requestOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// IdempotencyKey sets the idempotency key sent with a request. Stripe
+// returns the result of the first request made with a key for any later
+// request with the same key, so a call that may or may not have reached
+// Stripe can be safely repeated, eg by using a key derived from an order ID:
+//
+//	c.Charge(cusID, amount, stripe.IdempotencyKey("order-"+orderID))
+//
+// Every request other than a GET or DELETE is sent with a random key when
+// this option isn't used. GET and DELETE requests are idempotent already, so
+// Stripe doesn't use keys for them and this option is ignored.
+func IdempotencyKey(key string) RequestOption {
+	return func(o *requestOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// APIVersion sets the Stripe-Version sent with a request, which determines
+// the shape of the objects Stripe returns. Requests are sent with Version
+// unless the method's documentation says otherwise.
+func APIVersion(version string) RequestOption {
+	return func(o *requestOptions) {
+		o.version = version
+	}
+}
+
+// withVersion returns opts with APIVersion(version) in front of them, so
+// that it is used unless the caller set a version of their own.
+func withVersion(version string, opts []RequestOption) []RequestOption {
+	return append([]RequestOption{APIVersion(version)}, opts...)
+}
+
+// newIdempotencyKey returns a random version 4 UUID, which is the format
+// Stripe's own libraries use for idempotency keys.
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
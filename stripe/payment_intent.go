@@ -0,0 +1,243 @@
+package stripe
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// The statuses a PaymentIntent moves through. See
+// https://stripe.com/docs/payments/intents for how they fit together.
+const (
+	PaymentIntentRequiresPaymentMethod = "requires_payment_method"
+	PaymentIntentRequiresConfirmation  = "requires_confirmation"
+	PaymentIntentRequiresAction        = "requires_action"
+	PaymentIntentProcessing            = "processing"
+	PaymentIntentRequiresCapture       = "requires_capture"
+	PaymentIntentCanceled              = "canceled"
+	PaymentIntentSucceeded             = "succeeded"
+)
+
+// Types of NextAction.
+const (
+	NextActionRedirectToURL = "redirect_to_url"
+	NextActionUseStripeSDK  = "use_stripe_sdk"
+)
+
+// PaymentIntent tracks a payment from its creation through any
+// authentication the customer's bank requires, such as 3D Secure, until the
+// customer is charged. Unlike a Charge, a PaymentIntent that needs
+// authentication doesn't fail; its Status becomes requires_action and its
+// NextAction says what the customer needs to do.
+//
+// Requests for PaymentIntents are sent with PaymentIntentsVersion rather
+// than Version.
+type PaymentIntent struct {
+	ID                  string            `json:"id"`
+	Amount              int               `json:"amount"`
+	AmountCapturable    int               `json:"amount_capturable"`
+	AmountReceived      int               `json:"amount_received"`
+	CancellationReason  string            `json:"cancellation_reason"`
+	CaptureMethod       string            `json:"capture_method"`
+	ClientSecret        string            `json:"client_secret"`
+	Created             int64             `json:"created"`
+	Currency            Currency          `json:"currency"`
+	Customer            string            `json:"customer"`
+	Description         string            `json:"description"`
+	Livemode            bool              `json:"livemode"`
+	Metadata            map[string]string `json:"metadata"`
+	NextAction          *NextAction       `json:"next_action"`
+	PaymentMethod       string            `json:"payment_method"`
+	ReceiptEmail        string            `json:"receipt_email"`
+	StatementDescriptor string            `json:"statement_descriptor"`
+	Status              string            `json:"status"`
+}
+
+// NextAction is what a customer must do before a PaymentIntent can continue.
+// Type is either NextActionRedirectToURL, in which case the customer should
+// be redirected to RedirectToURL.URL to authenticate, or
+// NextActionUseStripeSDK, in which case Stripe.js must handle the action
+// using the PaymentIntent's ClientSecret.
+type NextAction struct {
+	Type          string         `json:"type"`
+	RedirectToURL *RedirectToURL `json:"redirect_to_url"`
+}
+
+// RedirectToURL is where to send a customer to authenticate a payment. Once
+// they're done they are sent back to ReturnURL.
+type RedirectToURL struct {
+	URL       string `json:"url"`
+	ReturnURL string `json:"return_url"`
+}
+
+// RedirectURL returns the URL the customer must be redirected to in order to
+// authenticate the payment, if the PaymentIntent is waiting on one.
+func (pi *PaymentIntent) RedirectURL() (string, bool) {
+	if pi.Status != PaymentIntentRequiresAction || pi.NextAction == nil || pi.NextAction.RedirectToURL == nil {
+		return "", false
+	}
+	return pi.NextAction.RedirectToURL.URL, true
+}
+
+// PaymentIntentParams are the fields used to create a PaymentIntent.
+type PaymentIntentParams struct {
+	// Amount is the amount to charge in the smallest unit of Currency, which
+	// defaults to DefaultCurrency.
+	Amount   int
+	Currency Currency
+	// Customer is the ID of the customer the payment is for, if any.
+	Customer string
+	// PaymentMethod is the ID of the payment method to charge, such as
+	// pm_card_visa.
+	PaymentMethod string
+	// Confirm confirms the PaymentIntent as soon as it is created, which
+	// requires PaymentMethod. ReturnURL is where the customer is sent back to
+	// after authenticating, if authentication is required.
+	Confirm   bool
+	ReturnURL string
+	// ManualCapture only authorizes the payment, leaving the PaymentIntent
+	// with the requires_capture status until CapturePaymentIntent is called.
+	ManualCapture bool
+	// Description, StatementDescriptor, ReceiptEmail and Metadata are passed
+	// on to the charge, just like the fields of ChargeParams.
+	Description         string
+	StatementDescriptor string
+	ReceiptEmail        string
+	Metadata            map[string]string
+}
+
+func (p *PaymentIntentParams) values() url.Values {
+	v := url.Values{}
+	v.Set("amount", strconv.Itoa(p.Amount))
+	v.Set("currency", DefaultCurrency)
+	if p.Currency != "" {
+		v.Set("currency", string(p.Currency.code()))
+	}
+	if p.Customer != "" {
+		v.Set("customer", p.Customer)
+	}
+	if p.PaymentMethod != "" {
+		v.Set("payment_method", p.PaymentMethod)
+	}
+	if p.Confirm {
+		v.Set("confirm", "true")
+	}
+	if p.ReturnURL != "" {
+		v.Set("return_url", p.ReturnURL)
+	}
+	if p.ManualCapture {
+		v.Set("capture_method", "manual")
+	}
+	if p.Description != "" {
+		v.Set("description", p.Description)
+	}
+	if p.StatementDescriptor != "" {
+		v.Set("statement_descriptor", p.StatementDescriptor)
+	}
+	if p.ReceiptEmail != "" {
+		v.Set("receipt_email", p.ReceiptEmail)
+	}
+	setMetadata(v, p.Metadata)
+	return v
+}
+
+// CreatePaymentIntent creates a PaymentIntent using params.
+func (c *Client) CreatePaymentIntent(params *PaymentIntentParams, opts ...RequestOption) (*PaymentIntent, error) {
+	return c.CreatePaymentIntentContext(context.Background(), params, opts...)
+}
+
+// CreatePaymentIntentContext is like CreatePaymentIntent, but ctx is used to
+// cancel its requests.
+func (c *Client) CreatePaymentIntentContext(ctx context.Context, params *PaymentIntentParams, opts ...RequestOption) (*PaymentIntent, error) {
+	var pi PaymentIntent
+	if err := c.call(ctx, http.MethodPost, "/payment_intents", params.values(), &pi, withVersion(PaymentIntentsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+// GetPaymentIntent retrieves the PaymentIntent with the given ID. It is
+// typically used once a customer returns from authenticating a payment to
+// find out whether it succeeded.
+func (c *Client) GetPaymentIntent(id string, opts ...RequestOption) (*PaymentIntent, error) {
+	return c.GetPaymentIntentContext(context.Background(), id, opts...)
+}
+
+// GetPaymentIntentContext is like GetPaymentIntent, but ctx is used to cancel
+// its requests.
+func (c *Client) GetPaymentIntentContext(ctx context.Context, id string, opts ...RequestOption) (*PaymentIntent, error) {
+	var pi PaymentIntent
+	if err := c.call(ctx, http.MethodGet, "/payment_intents/"+url.PathEscape(id), nil, &pi, withVersion(PaymentIntentsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+// ConfirmPaymentIntent confirms that the customer intends to pay with the
+// given payment method, or the one already set on the PaymentIntent if
+// paymentMethod is empty. If the payment requires authentication the
+// returned PaymentIntent has the requires_action status, and the customer
+// is sent back to returnURL once they have authenticated.
+func (c *Client) ConfirmPaymentIntent(id, paymentMethod, returnURL string, opts ...RequestOption) (*PaymentIntent, error) {
+	return c.ConfirmPaymentIntentContext(context.Background(), id, paymentMethod, returnURL, opts...)
+}
+
+// ConfirmPaymentIntentContext is like ConfirmPaymentIntent, but ctx is used
+// to cancel its requests.
+func (c *Client) ConfirmPaymentIntentContext(ctx context.Context, id, paymentMethod, returnURL string, opts ...RequestOption) (*PaymentIntent, error) {
+	v := url.Values{}
+	if paymentMethod != "" {
+		v.Set("payment_method", paymentMethod)
+	}
+	if returnURL != "" {
+		v.Set("return_url", returnURL)
+	}
+	var pi PaymentIntent
+	if err := c.call(ctx, http.MethodPost, "/payment_intents/"+url.PathEscape(id)+"/confirm", v, &pi, withVersion(PaymentIntentsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+// CapturePaymentIntent captures amount of a PaymentIntent created with
+// ManualCapture. An amount of 0 captures everything that was authorized, and
+// anything that isn't captured is released back to the customer.
+func (c *Client) CapturePaymentIntent(id string, amount int, opts ...RequestOption) (*PaymentIntent, error) {
+	return c.CapturePaymentIntentContext(context.Background(), id, amount, opts...)
+}
+
+// CapturePaymentIntentContext is like CapturePaymentIntent, but ctx is used
+// to cancel its requests.
+func (c *Client) CapturePaymentIntentContext(ctx context.Context, id string, amount int, opts ...RequestOption) (*PaymentIntent, error) {
+	v := url.Values{}
+	if amount > 0 {
+		v.Set("amount_to_capture", strconv.Itoa(amount))
+	}
+	var pi PaymentIntent
+	if err := c.call(ctx, http.MethodPost, "/payment_intents/"+url.PathEscape(id)+"/capture", v, &pi, withVersion(PaymentIntentsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+// CancelPaymentIntent cancels a PaymentIntent that hasn't succeeded yet.
+// reason is optional and must be one of duplicate, fraudulent,
+// requested_by_customer or abandoned.
+func (c *Client) CancelPaymentIntent(id, reason string, opts ...RequestOption) (*PaymentIntent, error) {
+	return c.CancelPaymentIntentContext(context.Background(), id, reason, opts...)
+}
+
+// CancelPaymentIntentContext is like CancelPaymentIntent, but ctx is used to
+// cancel its requests.
+func (c *Client) CancelPaymentIntentContext(ctx context.Context, id, reason string, opts ...RequestOption) (*PaymentIntent, error) {
+	v := url.Values{}
+	if reason != "" {
+		v.Set("cancellation_reason", reason)
+	}
+	var pi PaymentIntent
+	if err := c.call(ctx, http.MethodPost, "/payment_intents/"+url.PathEscape(id)+"/cancel", v, &pi, withVersion(PaymentIntentsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
@@ -0,0 +1,213 @@
+package stripe_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+const (
+	paymentMethodVisa            = "pm_card_visa"
+	paymentMethodThreeDSecure    = "pm_card_threeDSecure2Required"
+	paymentMethodChargeDeclined  = "pm_card_chargeDeclined"
+	paymentIntentReturnURL       = "https://swag.example.com/orders/confirm"
+	paymentIntentRedirectURLBase = "https://hooks.stripe.com/"
+)
+
+func createPaymentIntent(t *testing.T, c *stripe.Client, params *stripe.PaymentIntentParams) *stripe.PaymentIntent {
+	pi, err := c.CreatePaymentIntent(params)
+	if err != nil {
+		t.Fatalf("err creating payment intent. err = %v; want nil", err)
+	}
+	return pi
+}
+
+func hasStatus(t *testing.T, pi *stripe.PaymentIntent, status string) {
+	if pi.Status != status {
+		t.Errorf("Status = %s; want %s", pi.Status, status)
+	}
+}
+
+func TestClient_CreatePaymentIntent(t *testing.T) {
+	t.Run("confirmed", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		pi := createPaymentIntent(t, c, &stripe.PaymentIntentParams{
+			Amount:        2000,
+			PaymentMethod: paymentMethodVisa,
+			Confirm:       true,
+		})
+		if !strings.HasPrefix(pi.ID, "pi_") {
+			t.Errorf("ID = %s; want prefix %q", pi.ID, "pi_")
+		}
+		hasStatus(t, pi, stripe.PaymentIntentSucceeded)
+		if pi.AmountReceived != 2000 {
+			t.Errorf("AmountReceived = %d; want %d", pi.AmountReceived, 2000)
+		}
+		if _, ok := pi.RedirectURL(); ok {
+			t.Errorf("RedirectURL() ok = true; want false")
+		}
+	})
+	t.Run("requires action", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		pi := createPaymentIntent(t, c, &stripe.PaymentIntentParams{
+			Amount:        2000,
+			PaymentMethod: paymentMethodThreeDSecure,
+			Confirm:       true,
+			ReturnURL:     paymentIntentReturnURL,
+		})
+		hasStatus(t, pi, stripe.PaymentIntentRequiresAction)
+		if pi.NextAction == nil || pi.NextAction.Type != stripe.NextActionRedirectToURL {
+			t.Fatalf("NextAction = %+v; want a %s action", pi.NextAction, stripe.NextActionRedirectToURL)
+		}
+		if pi.NextAction.RedirectToURL.ReturnURL != paymentIntentReturnURL {
+			t.Errorf("ReturnURL = %s; want %s", pi.NextAction.RedirectToURL.ReturnURL, paymentIntentReturnURL)
+		}
+		redirect, ok := pi.RedirectURL()
+		if !ok || !strings.HasPrefix(redirect, paymentIntentRedirectURLBase) {
+			t.Errorf("RedirectURL() = %s, %t; want prefix %q, true", redirect, ok, paymentIntentRedirectURLBase)
+		}
+	})
+	t.Run("unconfirmed", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		pi := createPaymentIntent(t, c, &stripe.PaymentIntentParams{Amount: 2000})
+		hasStatus(t, pi, stripe.PaymentIntentRequiresPaymentMethod)
+		if pi.ClientSecret == "" {
+			t.Errorf("ClientSecret is empty; want it set for Stripe.js")
+		}
+	})
+	t.Run("card declined", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		_, err := c.CreatePaymentIntent(&stripe.PaymentIntentParams{
+			Amount:        2000,
+			PaymentMethod: paymentMethodChargeDeclined,
+			Confirm:       true,
+		})
+		if !errors.Is(err, stripe.ErrCardDeclined) {
+			t.Errorf("err = %v; want %v", err, stripe.ErrCardDeclined)
+		}
+	})
+}
+
+func TestClient_GetPaymentIntent(t *testing.T) {
+	t.Run("existing payment intent", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		created := createPaymentIntent(t, c, &stripe.PaymentIntentParams{
+			Amount:        2000,
+			PaymentMethod: paymentMethodThreeDSecure,
+			Confirm:       true,
+			ReturnURL:     paymentIntentReturnURL,
+		})
+		pi, err := c.GetPaymentIntent(created.ID)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if !reflect.DeepEqual(pi, created) {
+			t.Errorf("GetPaymentIntent() = %+v; want %+v", pi, created)
+		}
+	})
+	t.Run("missing payment intent", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		_, err := c.GetPaymentIntent("pi_missing")
+		hasStripeErr(t, err, stripe.ErrTypeInvalidRequest)
+	})
+}
+
+func TestClient_ConfirmPaymentIntent(t *testing.T) {
+	tests := map[string]struct {
+		paymentMethod string
+		status        string
+	}{
+		"succeeds":        {paymentMethodVisa, stripe.PaymentIntentSucceeded},
+		"requires action": {paymentMethodThreeDSecure, stripe.PaymentIntentRequiresAction},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, teardown := stripeClient(t)
+			defer teardown()
+			created := createPaymentIntent(t, c, &stripe.PaymentIntentParams{Amount: 2000})
+			pi, err := c.ConfirmPaymentIntent(created.ID, tc.paymentMethod, paymentIntentReturnURL)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			hasStatus(t, pi, tc.status)
+			if pi.PaymentMethod == "" {
+				t.Errorf("PaymentMethod is empty; want it set")
+			}
+		})
+	}
+}
+
+func TestClient_CapturePaymentIntent(t *testing.T) {
+	tests := map[string]struct {
+		amount int
+		want   int
+	}{
+		"full capture":    {0, 2000},
+		"partial capture": {1500, 1500},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, teardown := stripeClient(t)
+			defer teardown()
+			created := createPaymentIntent(t, c, &stripe.PaymentIntentParams{
+				Amount:        2000,
+				PaymentMethod: paymentMethodVisa,
+				Confirm:       true,
+				ManualCapture: true,
+			})
+			hasStatus(t, created, stripe.PaymentIntentRequiresCapture)
+			if created.AmountCapturable != 2000 {
+				t.Errorf("AmountCapturable = %d; want %d", created.AmountCapturable, 2000)
+			}
+			pi, err := c.CapturePaymentIntent(created.ID, tc.amount)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			hasStatus(t, pi, stripe.PaymentIntentSucceeded)
+			if pi.AmountReceived != tc.want {
+				t.Errorf("AmountReceived = %d; want %d", pi.AmountReceived, tc.want)
+			}
+		})
+	}
+}
+
+func TestClient_CancelPaymentIntent(t *testing.T) {
+	t.Run("awaiting action", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		created := createPaymentIntent(t, c, &stripe.PaymentIntentParams{
+			Amount:        2000,
+			PaymentMethod: paymentMethodThreeDSecure,
+			Confirm:       true,
+			ReturnURL:     paymentIntentReturnURL,
+		})
+		pi, err := c.CancelPaymentIntent(created.ID, "abandoned")
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		hasStatus(t, pi, stripe.PaymentIntentCanceled)
+		if pi.CancellationReason != "abandoned" {
+			t.Errorf("CancellationReason = %s; want %s", pi.CancellationReason, "abandoned")
+		}
+	})
+	t.Run("already succeeded", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		created := createPaymentIntent(t, c, &stripe.PaymentIntentParams{
+			Amount:        2000,
+			PaymentMethod: paymentMethodVisa,
+			Confirm:       true,
+		})
+		_, err := c.CancelPaymentIntent(created.ID, "")
+		hasStripeErr(t, err, stripe.ErrTypeInvalidRequest)
+	})
+}
@@ -0,0 +1,163 @@
+package stripe
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Product is something that is sold, such as a monthly swag box. How much it
+// costs is set by one or more Prices.
+type Product struct {
+	ID          string            `json:"id"`
+	Active      bool              `json:"active"`
+	Created     int64             `json:"created"`
+	Description string            `json:"description"`
+	Livemode    bool              `json:"livemode"`
+	Metadata    map[string]string `json:"metadata"`
+	Name        string            `json:"name"`
+}
+
+// ProductParams are the fields used to create a product. Name is required.
+type ProductParams struct {
+	Name        string
+	Description string
+	Metadata    map[string]string
+}
+
+func (p *ProductParams) values() url.Values {
+	v := url.Values{}
+	v.Set("name", p.Name)
+	if p.Description != "" {
+		v.Set("description", p.Description)
+	}
+	setMetadata(v, p.Metadata)
+	return v
+}
+
+// CreateProduct creates a product using params.
+func (c *Client) CreateProduct(params *ProductParams, opts ...RequestOption) (*Product, error) {
+	return c.CreateProductContext(context.Background(), params, opts...)
+}
+
+// CreateProductContext is like CreateProduct, but ctx is used to cancel its
+// requests.
+func (c *Client) CreateProductContext(ctx context.Context, params *ProductParams, opts ...RequestOption) (*Product, error) {
+	var prod Product
+	if err := c.call(ctx, http.MethodPost, "/products", params.values(), &prod, opts); err != nil {
+		return nil, err
+	}
+	return &prod, nil
+}
+
+// GetProduct retrieves the product with the given ID.
+func (c *Client) GetProduct(id string, opts ...RequestOption) (*Product, error) {
+	return c.GetProductContext(context.Background(), id, opts...)
+}
+
+// GetProductContext is like GetProduct, but ctx is used to cancel its
+// requests.
+func (c *Client) GetProductContext(ctx context.Context, id string, opts ...RequestOption) (*Product, error) {
+	var prod Product
+	if err := c.call(ctx, http.MethodGet, "/products/"+url.PathEscape(id), nil, &prod, opts); err != nil {
+		return nil, err
+	}
+	return &prod, nil
+}
+
+// The intervals a recurring Price can be billed at.
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+)
+
+// Price is how much a Product costs, and how often if it is recurring.
+type Price struct {
+	ID         string            `json:"id"`
+	Active     bool              `json:"active"`
+	Created    int64             `json:"created"`
+	Currency   Currency          `json:"currency"`
+	Livemode   bool              `json:"livemode"`
+	Metadata   map[string]string `json:"metadata"`
+	Product    string            `json:"product"`
+	Recurring  *Recurring        `json:"recurring"`
+	Type       string            `json:"type"`
+	UnitAmount int               `json:"unit_amount"`
+}
+
+// Recurring is how often a recurring Price is billed, eg every 3 months is
+// an Interval of month and an IntervalCount of 3.
+type Recurring struct {
+	Interval      string `json:"interval"`
+	IntervalCount int    `json:"interval_count"`
+}
+
+// Money returns the unit amount of p in its currency.
+func (p *Price) Money() Money {
+	return Money{Amount: p.UnitAmount, Currency: p.Currency}
+}
+
+// PriceParams are the fields used to create a price. Product and UnitAmount
+// are required.
+type PriceParams struct {
+	Product string
+	// UnitAmount is the amount charged for each unit. Its Currency defaults
+	// to DefaultCurrency.
+	UnitAmount Money
+	// Interval makes the price recurring, billed once every IntervalCount
+	// Intervals. IntervalCount defaults to 1, and prices without an Interval
+	// are only paid once.
+	Interval      string
+	IntervalCount int
+	Metadata      map[string]string
+}
+
+func (p *PriceParams) values() url.Values {
+	v := url.Values{}
+	v.Set("product", p.Product)
+	v.Set("unit_amount", strconv.Itoa(p.UnitAmount.Amount))
+	v.Set("currency", DefaultCurrency)
+	if p.UnitAmount.Currency != "" {
+		v.Set("currency", string(p.UnitAmount.Currency.code()))
+	}
+	if p.Interval != "" {
+		v.Set("recurring[interval]", p.Interval)
+	}
+	if p.IntervalCount > 0 {
+		v.Set("recurring[interval_count]", strconv.Itoa(p.IntervalCount))
+	}
+	setMetadata(v, p.Metadata)
+	return v
+}
+
+// CreatePrice creates a price using params.
+func (c *Client) CreatePrice(params *PriceParams, opts ...RequestOption) (*Price, error) {
+	return c.CreatePriceContext(context.Background(), params, opts...)
+}
+
+// CreatePriceContext is like CreatePrice, but ctx is used to cancel its
+// requests.
+func (c *Client) CreatePriceContext(ctx context.Context, params *PriceParams, opts ...RequestOption) (*Price, error) {
+	var price Price
+	if err := c.call(ctx, http.MethodPost, "/prices", params.values(), &price, opts); err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// GetPrice retrieves the price with the given ID.
+func (c *Client) GetPrice(id string, opts ...RequestOption) (*Price, error) {
+	return c.GetPriceContext(context.Background(), id, opts...)
+}
+
+// GetPriceContext is like GetPrice, but ctx is used to cancel its requests.
+func (c *Client) GetPriceContext(ctx context.Context, id string, opts ...RequestOption) (*Price, error) {
+	var price Price
+	if err := c.call(ctx, http.MethodGet, "/prices/"+url.PathEscape(id), nil, &price, opts); err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
@@ -0,0 +1,93 @@
+package stripe
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type Refund struct {
+	ID       string            `json:"id"`
+	Amount   int               `json:"amount"`
+	Charge   string            `json:"charge"`
+	Created  int64             `json:"created"`
+	Currency Currency          `json:"currency"`
+	Metadata map[string]string `json:"metadata"`
+	Reason   string            `json:"reason"`
+	Status   string            `json:"status"`
+}
+
+// Refund refunds amount of the charge with the given ID. An amount of 0
+// refunds whatever is left of the charge, and charges can be partially
+// refunded as many times as needed until their full amount is refunded.
+func (c *Client) Refund(chargeID string, amount int, opts ...RequestOption) (*Refund, error) {
+	return c.RefundContext(context.Background(), chargeID, amount, opts...)
+}
+
+// RefundContext is like Refund, but ctx is used to cancel its requests.
+func (c *Client) RefundContext(ctx context.Context, chargeID string, amount int, opts ...RequestOption) (*Refund, error) {
+	v := url.Values{}
+	v.Set("charge", chargeID)
+	if amount > 0 {
+		v.Set("amount", strconv.Itoa(amount))
+	}
+	var ref Refund
+	if err := c.call(ctx, http.MethodPost, "/refunds", v, &ref, opts); err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// GetRefund retrieves the refund with the given ID.
+func (c *Client) GetRefund(id string, opts ...RequestOption) (*Refund, error) {
+	return c.GetRefundContext(context.Background(), id, opts...)
+}
+
+// GetRefundContext is like GetRefund, but ctx is used to cancel its requests.
+func (c *Client) GetRefundContext(ctx context.Context, id string, opts ...RequestOption) (*Refund, error) {
+	var ref Refund
+	if err := c.call(ctx, http.MethodGet, "/refunds/"+url.PathEscape(id), nil, &ref, opts); err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// ListRefunds returns an iterator over the refunds of the charge with the
+// given ID, newest first. If chargeID is empty every refund is listed.
+func (c *Client) ListRefunds(chargeID string, params *ListParams, opts ...RequestOption) *RefundIter {
+	return c.ListRefundsContext(context.Background(), chargeID, params, opts...)
+}
+
+// ListRefundsContext is like ListRefunds, but ctx is used to cancel its
+// requests.
+func (c *Client) ListRefundsContext(ctx context.Context, chargeID string, params *ListParams, opts ...RequestOption) *RefundIter {
+	it := newIter(ctx, c, "/refunds", params, opts)
+	if chargeID != "" {
+		it.params.Set("charge", chargeID)
+	}
+	return &RefundIter{iter: it}
+}
+
+// RefundIter iterates over a list of refunds. It is used just like a
+// CustomerIter.
+type RefundIter struct {
+	*iter
+	ref *Refund
+}
+
+// Next advances to the next refund, fetching the next page of refunds if
+// needed.
+func (it *RefundIter) Next() bool {
+	var ref Refund
+	if !it.next(&ref) {
+		return false
+	}
+	it.ref = &ref
+	return true
+}
+
+// Refund returns the current refund.
+func (it *RefundIter) Refund() *Refund {
+	return it.ref
+}
@@ -0,0 +1,169 @@
+package stripe_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+func createCharge(t *testing.T, c *stripe.Client, amount int) *stripe.Charge {
+	cus := createCustomer(t, c, tokenAmex)
+	charge, err := c.Charge(cus.ID, amount)
+	if err != nil {
+		t.Fatalf("err creating charge. err = %v; want nil", err)
+	}
+	return charge
+}
+
+func TestClient_Refund(t *testing.T) {
+	type checkFn func(*testing.T, *stripe.Charge, *stripe.Refund, error)
+	check := func(fns ...checkFn) []checkFn { return fns }
+
+	hasNoErr := func() checkFn {
+		return func(t *testing.T, charge *stripe.Charge, ref *stripe.Refund, err error) {
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+		}
+	}
+	hasErrType := func(typee string) checkFn {
+		return func(t *testing.T, charge *stripe.Charge, ref *stripe.Refund, err error) {
+			hasStripeErr(t, err, typee)
+		}
+	}
+	hasIDPrefix := func() checkFn {
+		return func(t *testing.T, charge *stripe.Charge, ref *stripe.Refund, err error) {
+			if !strings.HasPrefix(ref.ID, "re_") {
+				t.Errorf("ID = %s; want prefix %q", ref.ID, "re_")
+			}
+		}
+	}
+	hasAmount := func(amount int) checkFn {
+		return func(t *testing.T, charge *stripe.Charge, ref *stripe.Refund, err error) {
+			if ref.Amount != amount {
+				t.Errorf("Amount = %d; want %d", ref.Amount, amount)
+			}
+		}
+	}
+	refundsCharge := func() checkFn {
+		return func(t *testing.T, charge *stripe.Charge, ref *stripe.Refund, err error) {
+			if ref.Charge != charge.ID {
+				t.Errorf("Charge = %s; want %s", ref.Charge, charge.ID)
+			}
+		}
+	}
+
+	chargeOf := func(amount int) func(*testing.T, *stripe.Client) *stripe.Charge {
+		return func(t *testing.T, c *stripe.Client) *stripe.Charge {
+			return createCharge(t, c, amount)
+		}
+	}
+	refundedChargeOf := func(amount int) func(*testing.T, *stripe.Client) *stripe.Charge {
+		return func(t *testing.T, c *stripe.Client) *stripe.Charge {
+			charge := createCharge(t, c, amount)
+			if _, err := c.Refund(charge.ID, 0); err != nil {
+				t.Fatalf("err refunding charge. err = %v; want nil", err)
+			}
+			return charge
+		}
+	}
+
+	tests := map[string]struct {
+		charge func(*testing.T, *stripe.Client) *stripe.Charge
+		amount int
+		checks []checkFn
+	}{
+		"full refund": {
+			charge: chargeOf(1234),
+			checks: check(hasNoErr(), hasIDPrefix(), hasAmount(1234), refundsCharge()),
+		},
+		"partial refund": {
+			charge: chargeOf(1234),
+			amount: 500,
+			checks: check(hasNoErr(), hasIDPrefix(), hasAmount(500), refundsCharge()),
+		},
+		"already refunded": {
+			charge: refundedChargeOf(1234),
+			checks: check(hasErrType(stripe.ErrTypeInvalidRequest)),
+		},
+		"amount too large": {
+			charge: chargeOf(1234),
+			amount: 5000,
+			checks: check(hasErrType(stripe.ErrTypeInvalidRequest)),
+		},
+		"missing charge": {
+			charge: func(*testing.T, *stripe.Client) *stripe.Charge {
+				return &stripe.Charge{ID: "ch_missing"}
+			},
+			checks: check(hasErrType(stripe.ErrTypeInvalidRequest)),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, teardown := stripeClient(t)
+			defer teardown()
+			charge := tc.charge(t, c)
+			ref, err := c.Refund(charge.ID, tc.amount)
+			for _, check := range tc.checks {
+				check(t, charge, ref, err)
+			}
+		})
+	}
+}
+
+func TestClient_GetRefund(t *testing.T) {
+	t.Run("existing refund", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		charge := createCharge(t, c, 2500)
+		created, err := c.Refund(charge.ID, 1000)
+		if err != nil {
+			t.Fatalf("Refund() err = %v; want nil", err)
+		}
+		ref, err := c.GetRefund(created.ID)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if !reflect.DeepEqual(ref, created) {
+			t.Errorf("GetRefund() = %+v; want %+v", ref, created)
+		}
+	})
+	t.Run("missing refund", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		_, err := c.GetRefund("re_missing")
+		hasStripeErr(t, err, stripe.ErrTypeInvalidRequest)
+	})
+}
+
+func TestClient_ListRefunds(t *testing.T) {
+	t.Run("refunds for a charge", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		charge := createCharge(t, c, 3000)
+		var want []string
+		for _, amount := range []int{1000, 500} {
+			ref, err := c.Refund(charge.ID, amount)
+			if err != nil {
+				t.Fatalf("Refund() err = %v; want nil", err)
+			}
+			want = append([]string{ref.ID}, want...)
+		}
+		it := c.ListRefunds(charge.ID, nil)
+		var got []string
+		for it.Next() {
+			if it.Refund().Charge != charge.ID {
+				t.Errorf("Charge = %s; want %s", it.Refund().Charge, charge.ID)
+			}
+			got = append(got, it.Refund().ID)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("Err() = %v; want nil", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("refund IDs = %v; want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package stripe
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how a Client retries requests that fail for reasons
+// that might be temporary, such as a network error, a 429 Too Many Requests
+// response, a 409 Conflict or a 5xx server error. If Stripe sends a
+// Stripe-Should-Retry header its advice is always followed instead.
+//
+// Only requests that are safe to repeat are retried, which are GET and
+// DELETE requests along with any sent with an idempotency key. Client sends a
+// key with every other request and uses the same key for every attempt of a
+// call, so Stripe only acts on a call once however many attempts it takes.
+type RetryPolicy struct {
+	// MaxAttempts is the most attempts made for a single call, including the
+	// first. Defaults to 3.
+	MaxAttempts int
+
+	// InitialBackoff is how long to wait before the first retry, which
+	// doubles for every retry after that up to MaxBackoff. Each wait is then
+	// reduced by a random amount of up to half so that clients that failed
+	// together don't all retry together. They default to 500ms and 8s.
+	// MaxBackoff also caps waits requested by a Retry-After header, so a
+	// server can't stall a call for longer than that.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Sleep is used to wait between attempts and defaults to time.Sleep,
+	// except that waits are cut short if a call's context is done. Tests can
+	// replace it to avoid waiting.
+	Sleep func(time.Duration)
+}
+
+// retry reports whether a call should be retried after the given attempt,
+// which returned res and err, and how long to wait before doing so. A nil
+// policy never retries.
+func (p *RetryPolicy) retry(req *http.Request, attempt int, res *http.Response, err error) (time.Duration, bool) {
+	if p == nil || attempt >= p.maxAttempts() || !idempotent(req) {
+		return 0, false
+	}
+	if err != nil {
+		return p.backoff(attempt), true
+	}
+	switch res.Header.Get("Stripe-Should-Retry") {
+	case "true":
+		return p.wait(attempt, res), true
+	case "false":
+		return 0, false
+	}
+	switch {
+	case res.StatusCode == http.StatusConflict,
+		res.StatusCode == http.StatusTooManyRequests,
+		res.StatusCode >= 500:
+		return p.wait(attempt, res), true
+	}
+	return 0, false
+}
+
+// wait returns how long to wait before retrying a request that received res.
+// Retry-After headers are honoured with no jitter, up to MaxBackoff.
+func (p *RetryPolicy) wait(attempt int, res *http.Response) time.Duration {
+	if secs, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && secs >= 0 {
+		d := time.Duration(secs) * time.Second
+		if max := p.maxBackoff(); d > max {
+			d = max
+		}
+		return d
+	}
+	return p.backoff(attempt)
+}
+
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	initial, max := p.InitialBackoff, p.maxBackoff()
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	d := initial
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (p *RetryPolicy) maxBackoff() time.Duration {
+	if p.MaxBackoff <= 0 {
+		return 8 * time.Second
+	}
+	return p.MaxBackoff
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return 3
+	}
+	return p.MaxAttempts
+}
+
+// sleep waits for d, returning early with ctx's error if ctx is done first.
+func (p *RetryPolicy) sleep(ctx context.Context, d time.Duration) error {
+	if p.Sleep != nil {
+		p.Sleep(d)
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// idempotent reports whether req can be safely sent more than once.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
@@ -0,0 +1,226 @@
+package stripe_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+type scriptedResponse struct {
+	status  int
+	headers map[string]string
+}
+
+func TestClient_Retry(t *testing.T) {
+	const backoff = 100 * time.Millisecond
+	retry500 := scriptedResponse{status: http.StatusInternalServerError}
+	ok := scriptedResponse{status: http.StatusOK}
+
+	tests := map[string]struct {
+		policy    *stripe.RetryPolicy
+		responses []scriptedResponse
+		wantCalls int
+		wantErr   bool
+		// checkSleeps is called with the durations slept between attempts.
+		checkSleeps func(*testing.T, []time.Duration)
+	}{
+		"no policy": {
+			responses: []scriptedResponse{retry500, ok},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		"server error then success": {
+			policy:    &stripe.RetryPolicy{},
+			responses: []scriptedResponse{{status: http.StatusServiceUnavailable}, ok},
+			wantCalls: 2,
+		},
+		"conflict then success": {
+			policy:    &stripe.RetryPolicy{},
+			responses: []scriptedResponse{{status: http.StatusConflict}, ok},
+			wantCalls: 2,
+		},
+		"retry after": {
+			policy: &stripe.RetryPolicy{},
+			responses: []scriptedResponse{
+				{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "2"}},
+				ok,
+			},
+			wantCalls: 2,
+			checkSleeps: func(t *testing.T, sleeps []time.Duration) {
+				if want := []time.Duration{2 * time.Second}; !reflect.DeepEqual(sleeps, want) {
+					t.Errorf("sleeps = %v; want %v", sleeps, want)
+				}
+			},
+		},
+		"retry after is capped": {
+			policy: &stripe.RetryPolicy{MaxBackoff: 5 * time.Second},
+			responses: []scriptedResponse{
+				{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "3600"}},
+				ok,
+			},
+			wantCalls: 2,
+			checkSleeps: func(t *testing.T, sleeps []time.Duration) {
+				if want := []time.Duration{5 * time.Second}; !reflect.DeepEqual(sleeps, want) {
+					t.Errorf("sleeps = %v; want %v", sleeps, want)
+				}
+			},
+		},
+		"retry after is capped by default": {
+			policy: &stripe.RetryPolicy{},
+			responses: []scriptedResponse{
+				{status: http.StatusServiceUnavailable, headers: map[string]string{"Retry-After": "3600"}},
+				ok,
+			},
+			wantCalls: 2,
+			checkSleeps: func(t *testing.T, sleeps []time.Duration) {
+				if want := []time.Duration{8 * time.Second}; !reflect.DeepEqual(sleeps, want) {
+					t.Errorf("sleeps = %v; want %v", sleeps, want)
+				}
+			},
+		},
+		"should retry false": {
+			policy: &stripe.RetryPolicy{},
+			responses: []scriptedResponse{
+				{status: http.StatusInternalServerError, headers: map[string]string{"Stripe-Should-Retry": "false"}},
+				ok,
+			},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		"should retry true": {
+			policy: &stripe.RetryPolicy{},
+			responses: []scriptedResponse{
+				{status: http.StatusBadRequest, headers: map[string]string{"Stripe-Should-Retry": "true"}},
+				ok,
+			},
+			wantCalls: 2,
+		},
+		"client errors are not retried": {
+			policy:    &stripe.RetryPolicy{},
+			responses: []scriptedResponse{{status: http.StatusPaymentRequired}, ok},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		"max attempts": {
+			policy:    &stripe.RetryPolicy{MaxAttempts: 4, InitialBackoff: backoff, MaxBackoff: 3 * backoff},
+			responses: []scriptedResponse{retry500, retry500, retry500, retry500, ok},
+			wantCalls: 4,
+			wantErr:   true,
+			checkSleeps: func(t *testing.T, sleeps []time.Duration) {
+				// Backoff doubles up to the max and is reduced by up to half.
+				maxes := []time.Duration{backoff, 2 * backoff, 3 * backoff}
+				if len(sleeps) != len(maxes) {
+					t.Fatalf("sleeps = %v; want %d of them", sleeps, len(maxes))
+				}
+				for i, max := range maxes {
+					if sleeps[i] < max/2 || sleeps[i] > max {
+						t.Errorf("sleeps[%d] = %v; want between %v and %v", i, sleeps[i], max/2, max)
+					}
+				}
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			var keys []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := tc.responses[calls]
+				calls++
+				keys = append(keys, r.Header.Get(stripe.IdempotencyKeyHeader))
+				for k, v := range resp.headers {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(resp.status)
+				if resp.status >= 400 {
+					fmt.Fprint(w, `{"error": {"type": "api_error", "message": "Something went wrong."}}`)
+					return
+				}
+				fmt.Fprint(w, `{"id": "ch_123", "amount": 1234}`)
+			}))
+			defer server.Close()
+			var sleeps []time.Duration
+			if tc.policy != nil {
+				tc.policy.Sleep = func(d time.Duration) {
+					sleeps = append(sleeps, d)
+				}
+			}
+			c := stripe.Client{
+				Key:     "gibberish-key",
+				BaseURL: server.URL,
+				Retry:   tc.policy,
+			}
+			_, err := c.Charge("cus_123", 1234)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("err = %v; want error = %t", err, tc.wantErr)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("calls = %d; want %d", calls, tc.wantCalls)
+			}
+			if len(sleeps) != calls-1 {
+				t.Errorf("sleeps = %d; want %d", len(sleeps), calls-1)
+			}
+			for _, key := range keys {
+				if key == "" || key != keys[0] {
+					t.Errorf("idempotency keys = %v; want the same key for every attempt", keys)
+					break
+				}
+			}
+			if tc.checkSleeps != nil {
+				tc.checkSleeps(t, sleeps)
+			}
+		})
+	}
+}
+
+type flakyClient struct {
+	failures int
+	calls    int
+}
+
+func (fc *flakyClient) Do(req *http.Request) (*http.Response, error) {
+	fc.calls++
+	if fc.calls <= fc.failures {
+		return nil, errors.New("connection reset by peer")
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func TestClient_Retry_networkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "cus_123"}`)
+	}))
+	defer server.Close()
+	fc := &flakyClient{failures: 2}
+	c := stripe.Client{
+		Key:        "gibberish-key",
+		BaseURL:    server.URL,
+		HttpClient: fc,
+		Retry:      &stripe.RetryPolicy{Sleep: func(time.Duration) {}},
+	}
+	cus, err := c.GetCustomer("cus_123")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if cus.ID != "cus_123" {
+		t.Errorf("ID = %s; want %s", cus.ID, "cus_123")
+	}
+	if fc.calls != 3 {
+		t.Errorf("calls = %d; want 3", fc.calls)
+	}
+
+	fc = &flakyClient{failures: 3}
+	c.HttpClient = fc
+	if _, err := c.GetCustomer("cus_123"); err == nil {
+		t.Errorf("err = nil; want the network error after running out of attempts")
+	}
+	if fc.calls != 3 {
+		t.Errorf("calls = %d; want 3", fc.calls)
+	}
+}
@@ -0,0 +1,154 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Some of the statuses a Subscription can have. Subscriptions whose first
+// payment fails are incomplete until it is paid, and become past_due if a
+// later payment fails.
+const (
+	SubscriptionActive     = "active"
+	SubscriptionIncomplete = "incomplete"
+	SubscriptionPastDue    = "past_due"
+	SubscriptionCanceled   = "canceled"
+)
+
+// Subscription bills a customer for one or more recurring Prices every
+// billing period, creating an Invoice each time.
+//
+// Requests for subscriptions are sent with SubscriptionsVersion rather than
+// Version.
+type Subscription struct {
+	ID                 string               `json:"id"`
+	CancelAtPeriodEnd  bool                 `json:"cancel_at_period_end"`
+	Created            int64                `json:"created"`
+	CurrentPeriodEnd   int64                `json:"current_period_end"`
+	CurrentPeriodStart int64                `json:"current_period_start"`
+	Customer           string               `json:"customer"`
+	Items              SubscriptionItemList `json:"items"`
+	LatestInvoice      string               `json:"latest_invoice"`
+	Livemode           bool                 `json:"livemode"`
+	Metadata           map[string]string    `json:"metadata"`
+	Status             string               `json:"status"`
+}
+
+// SubscriptionItemList is the list of items in a Subscription. Stripe
+// includes up to 10 items, and HasMore is true if there are more than that.
+type SubscriptionItemList struct {
+	Data    []SubscriptionItem `json:"data"`
+	HasMore bool               `json:"has_more"`
+}
+
+// SubscriptionItem is a Price a customer is subscribed to and how many of
+// it they get each billing period.
+type SubscriptionItem struct {
+	ID       string `json:"id"`
+	Price    Price  `json:"price"`
+	Quantity int    `json:"quantity"`
+}
+
+// SubscriptionParams are the fields used to create a subscription. Customer
+// and at least one item are required.
+type SubscriptionParams struct {
+	Customer string
+	Items    []SubscriptionItemParams
+	Metadata map[string]string
+}
+
+// SubscriptionItemParams are the fields of an item in SubscriptionParams.
+// Quantity defaults to 1.
+type SubscriptionItemParams struct {
+	Price    string
+	Quantity int
+}
+
+func (p *SubscriptionParams) values() url.Values {
+	v := url.Values{}
+	v.Set("customer", p.Customer)
+	for i, item := range p.Items {
+		v.Set(fmt.Sprintf("items[%d][price]", i), item.Price)
+		if item.Quantity > 0 {
+			v.Set(fmt.Sprintf("items[%d][quantity]", i), strconv.Itoa(item.Quantity))
+		}
+	}
+	setMetadata(v, p.Metadata)
+	return v
+}
+
+// CreateSubscription subscribes a customer to the prices in params. The
+// first invoice is paid immediately using the customer's default payment
+// source, and if that fails the subscription is incomplete rather than an
+// error being returned.
+func (c *Client) CreateSubscription(params *SubscriptionParams, opts ...RequestOption) (*Subscription, error) {
+	return c.CreateSubscriptionContext(context.Background(), params, opts...)
+}
+
+// CreateSubscriptionContext is like CreateSubscription, but ctx is used to
+// cancel its requests.
+func (c *Client) CreateSubscriptionContext(ctx context.Context, params *SubscriptionParams, opts ...RequestOption) (*Subscription, error) {
+	var sub Subscription
+	if err := c.call(ctx, http.MethodPost, "/subscriptions", params.values(), &sub, withVersion(SubscriptionsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetSubscription retrieves the subscription with the given ID.
+func (c *Client) GetSubscription(id string, opts ...RequestOption) (*Subscription, error) {
+	return c.GetSubscriptionContext(context.Background(), id, opts...)
+}
+
+// GetSubscriptionContext is like GetSubscription, but ctx is used to cancel
+// its requests.
+func (c *Client) GetSubscriptionContext(ctx context.Context, id string, opts ...RequestOption) (*Subscription, error) {
+	var sub Subscription
+	if err := c.call(ctx, http.MethodGet, "/subscriptions/"+url.PathEscape(id), nil, &sub, withVersion(SubscriptionsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// CancelSubscriptionAtPeriodEnd cancels the subscription with the given ID
+// once its current billing period ends. The subscription stays active until
+// then and the customer isn't billed again.
+func (c *Client) CancelSubscriptionAtPeriodEnd(id string, opts ...RequestOption) (*Subscription, error) {
+	return c.CancelSubscriptionAtPeriodEndContext(context.Background(), id, opts...)
+}
+
+// CancelSubscriptionAtPeriodEndContext is like CancelSubscriptionAtPeriodEnd,
+// but ctx is used to cancel its requests.
+func (c *Client) CancelSubscriptionAtPeriodEndContext(ctx context.Context, id string, opts ...RequestOption) (*Subscription, error) {
+	v := url.Values{}
+	v.Set("cancel_at_period_end", "true")
+	var sub Subscription
+	if err := c.call(ctx, http.MethodPost, "/subscriptions/"+url.PathEscape(id), v, &sub, withVersion(SubscriptionsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// UpdateSubscriptionQuantity changes the quantity of the item with the
+// given ID in the subscription with the given ID. The change is prorated,
+// so the customer is credited or charged for the rest of the current billing
+// period on their next invoice.
+func (c *Client) UpdateSubscriptionQuantity(id, itemID string, quantity int, opts ...RequestOption) (*Subscription, error) {
+	return c.UpdateSubscriptionQuantityContext(context.Background(), id, itemID, quantity, opts...)
+}
+
+// UpdateSubscriptionQuantityContext is like UpdateSubscriptionQuantity, but
+// ctx is used to cancel its requests.
+func (c *Client) UpdateSubscriptionQuantityContext(ctx context.Context, id, itemID string, quantity int, opts ...RequestOption) (*Subscription, error) {
+	v := url.Values{}
+	v.Set("items[0][id]", itemID)
+	v.Set("items[0][quantity]", strconv.Itoa(quantity))
+	var sub Subscription
+	if err := c.call(ctx, http.MethodPost, "/subscriptions/"+url.PathEscape(id), v, &sub, withVersion(SubscriptionsVersion, opts)); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
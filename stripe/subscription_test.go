@@ -0,0 +1,164 @@
+package stripe_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+// subscribe creates a customer with the given token and subscribes them to
+// quantity of a new monthly price of 15.00 USD.
+func subscribe(t *testing.T, c *stripe.Client, token string, quantity int) (*stripe.Customer, *stripe.Subscription) {
+	cus := createCustomer(t, c, token)
+	prod, err := c.CreateProduct(&stripe.ProductParams{Name: "Swag box"})
+	if err != nil {
+		t.Fatalf("err creating product. err = %v; want nil", err)
+	}
+	price, err := c.CreatePrice(&stripe.PriceParams{
+		Product:    prod.ID,
+		UnitAmount: stripe.Money{Amount: 1500, Currency: stripe.USD},
+		Interval:   stripe.IntervalMonth,
+	})
+	if err != nil {
+		t.Fatalf("err creating price. err = %v; want nil", err)
+	}
+	sub, err := c.CreateSubscription(&stripe.SubscriptionParams{
+		Customer: cus.ID,
+		Items:    []stripe.SubscriptionItemParams{{Price: price.ID, Quantity: quantity}},
+	})
+	if err != nil {
+		t.Fatalf("err creating subscription. err = %v; want nil", err)
+	}
+	return cus, sub
+}
+
+func TestClient_CreatePrice(t *testing.T) {
+	tests := map[string]struct {
+		params        stripe.PriceParams
+		wantRecurring *stripe.Recurring
+		wantType      string
+	}{
+		"every three months": {
+			params: stripe.PriceParams{
+				UnitAmount:    stripe.Money{Amount: 4000, Currency: stripe.GBP},
+				Interval:      stripe.IntervalMonth,
+				IntervalCount: 3,
+			},
+			wantRecurring: &stripe.Recurring{Interval: stripe.IntervalMonth, IntervalCount: 3},
+			wantType:      "recurring",
+		},
+		"one time": {
+			params: stripe.PriceParams{
+				UnitAmount: stripe.Money{Amount: 2500, Currency: stripe.EUR},
+			},
+			wantType: "one_time",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, teardown := stripeClient(t)
+			defer teardown()
+			prod, err := c.CreateProduct(&stripe.ProductParams{
+				Name:     "Swag box",
+				Metadata: map[string]string{"campaign": "1"},
+			})
+			if err != nil {
+				t.Fatalf("CreateProduct() err = %v; want nil", err)
+			}
+			if prod.Name != "Swag box" || !prod.Active {
+				t.Errorf("CreateProduct() = %+v; want an active product named %q", prod, "Swag box")
+			}
+			tc.params.Product = prod.ID
+			price, err := c.CreatePrice(&tc.params)
+			if err != nil {
+				t.Fatalf("CreatePrice() err = %v; want nil", err)
+			}
+			if price.Product != prod.ID {
+				t.Errorf("Product = %s; want %s", price.Product, prod.ID)
+			}
+			if price.Money() != tc.params.UnitAmount {
+				t.Errorf("Money() = %v; want %v", price.Money(), tc.params.UnitAmount)
+			}
+			if price.Type != tc.wantType {
+				t.Errorf("Type = %s; want %s", price.Type, tc.wantType)
+			}
+			if !reflect.DeepEqual(price.Recurring, tc.wantRecurring) {
+				t.Errorf("Recurring = %+v; want %+v", price.Recurring, tc.wantRecurring)
+			}
+		})
+	}
+}
+
+func TestClient_CreateSubscription(t *testing.T) {
+	t.Run("active", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		cus, sub := subscribe(t, c, tokenVisa, 2)
+		if sub.Status != stripe.SubscriptionActive {
+			t.Errorf("Status = %s; want %s", sub.Status, stripe.SubscriptionActive)
+		}
+		if sub.Customer != cus.ID {
+			t.Errorf("Customer = %s; want %s", sub.Customer, cus.ID)
+		}
+		if len(sub.Items.Data) != 1 || sub.Items.Data[0].Quantity != 2 {
+			t.Fatalf("Items = %+v; want one item with a quantity of 2", sub.Items)
+		}
+		if got := sub.Items.Data[0].Price.Money().String(); got != "$15.00" {
+			t.Errorf("item price = %s; want %s", got, "$15.00")
+		}
+	})
+	t.Run("payment fails", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		_, sub := subscribe(t, c, tokenChargeCustomerFail, 1)
+		if sub.Status != stripe.SubscriptionIncomplete {
+			t.Errorf("Status = %s; want %s", sub.Status, stripe.SubscriptionIncomplete)
+		}
+	})
+}
+
+func TestClient_CancelSubscriptionAtPeriodEnd(t *testing.T) {
+	c, teardown := stripeClient(t)
+	defer teardown()
+	_, created := subscribe(t, c, tokenVisa, 1)
+	sub, err := c.CancelSubscriptionAtPeriodEnd(created.ID)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if !sub.CancelAtPeriodEnd {
+		t.Errorf("CancelAtPeriodEnd = false; want true")
+	}
+	if sub.Status != stripe.SubscriptionActive {
+		t.Errorf("Status = %s; want %s until the period ends", sub.Status, stripe.SubscriptionActive)
+	}
+	got, err := c.GetSubscription(created.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription() err = %v; want nil", err)
+	}
+	if !reflect.DeepEqual(got, sub) {
+		t.Errorf("GetSubscription() = %+v; want %+v", got, sub)
+	}
+}
+
+func TestClient_UpdateSubscriptionQuantity(t *testing.T) {
+	t.Run("existing item", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		_, created := subscribe(t, c, tokenVisa, 1)
+		item := created.Items.Data[0]
+		sub, err := c.UpdateSubscriptionQuantity(created.ID, item.ID, 3)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if len(sub.Items.Data) != 1 || sub.Items.Data[0].ID != item.ID || sub.Items.Data[0].Quantity != 3 {
+			t.Errorf("Items = %+v; want item %s with a quantity of 3", sub.Items, item.ID)
+		}
+	})
+	t.Run("missing subscription", func(t *testing.T) {
+		c, teardown := stripeClient(t)
+		defer teardown()
+		_, err := c.UpdateSubscriptionQuantity("sub_missing", "si_missing", 3)
+		hasStripeErr(t, err, stripe.ErrTypeInvalidRequest)
+	})
+}
@@ -0,0 +1,266 @@
+// Package webhook receives events that Stripe sends to webhook endpoints,
+// such as a charge failing or a customer being deleted.
+//
+// Handler verifies that each event was signed by Stripe before passing it
+// to the callbacks registered for its type:
+//
+//	h := &webhook.Handler{Secret: "whsec_..."}
+//	h.OnChargeFailed(func(e webhook.ChargeEvent) error {
+//	  return orders.MarkUnpaid(e.Charge.ID)
+//	})
+//	http.Handle("/stripe/webhook", h)
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joncalhoun/twg/stripe"
+)
+
+const (
+	// SignatureHeader is the header Stripe signs events with.
+	SignatureHeader = "Stripe-Signature"
+
+	// DefaultTolerance is how old an event's signature can be before Handler
+	// rejects it, which prevents old events being replayed.
+	DefaultTolerance = 5 * time.Minute
+
+	// maxBodyBytes limits the size of the events Handler will read.
+	maxBodyBytes = 1 << 20
+)
+
+// The types of events with typed callbacks on Handler.
+const (
+	ChargeSucceeded = "charge.succeeded"
+	ChargeFailed    = "charge.failed"
+	ChargeRefunded  = "charge.refunded"
+	CustomerDeleted = "customer.deleted"
+)
+
+// Errors returned by Verify.
+var (
+	ErrInvalidHeader    = errors.New("webhook: invalid Stripe-Signature header")
+	ErrNoValidSignature = errors.New("webhook: no valid signature")
+	ErrTooOld           = errors.New("webhook: signature timestamp is outside the tolerance")
+	ErrNoSecret         = errors.New("webhook: no signing secret")
+)
+
+// Event is an event sent by Stripe. Data.Object holds the object the event
+// is about, such as a charge, which the typed events decode.
+type Event struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Created  int64  `json:"created"`
+	Livemode bool   `json:"livemode"`
+	Data     struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// ChargeEvent is an event about a charge, such as charge.succeeded.
+type ChargeEvent struct {
+	Event
+	Charge stripe.Charge
+}
+
+// CustomerEvent is an event about a customer, such as customer.deleted.
+type CustomerEvent struct {
+	Event
+	Customer stripe.Customer
+}
+
+// Handler is an http.Handler for a Stripe webhook endpoint. It responds with
+// a 400 Bad Request to requests without a valid signature and with a 500
+// Internal Server Error if a callback returns an error, which makes Stripe
+// send the event again later. Events without a callback are acknowledged and
+// ignored.
+type Handler struct {
+	// Secret is the endpoint's signing secret, which starts with "whsec_".
+	// Anyone could sign events with an empty secret, so every request is
+	// rejected with a 500 status until it is set.
+	Secret string
+
+	// Tolerance defaults to DefaultTolerance. A negative Tolerance disables
+	// the check, which should only be needed in tests.
+	Tolerance time.Duration
+
+	// Now returns the current time and defaults to time.Now.
+	Now func() time.Time
+
+	callbacks map[string][]func(Event) error
+}
+
+// On registers fn to be called with every event of the given type. Multiple
+// callbacks can be registered for a type and they are called in order until
+// one returns an error.
+func (h *Handler) On(eventType string, fn func(Event) error) {
+	if h.callbacks == nil {
+		h.callbacks = make(map[string][]func(Event) error)
+	}
+	h.callbacks[eventType] = append(h.callbacks[eventType], fn)
+}
+
+// OnChargeSucceeded registers fn to be called for charge.succeeded events.
+func (h *Handler) OnChargeSucceeded(fn func(ChargeEvent) error) {
+	h.On(ChargeSucceeded, chargeCallback(fn))
+}
+
+// OnChargeFailed registers fn to be called for charge.failed events.
+func (h *Handler) OnChargeFailed(fn func(ChargeEvent) error) {
+	h.On(ChargeFailed, chargeCallback(fn))
+}
+
+// OnChargeRefunded registers fn to be called for charge.refunded events.
+func (h *Handler) OnChargeRefunded(fn func(ChargeEvent) error) {
+	h.On(ChargeRefunded, chargeCallback(fn))
+}
+
+// OnCustomerDeleted registers fn to be called for customer.deleted events.
+func (h *Handler) OnCustomerDeleted(fn func(CustomerEvent) error) {
+	h.On(CustomerDeleted, func(e Event) error {
+		ce := CustomerEvent{Event: e}
+		if err := json.Unmarshal(e.Data.Object, &ce.Customer); err != nil {
+			return err
+		}
+		return fn(ce)
+	})
+}
+
+func chargeCallback(fn func(ChargeEvent) error) func(Event) error {
+	return func(e Event) error {
+		ce := ChargeEvent{Event: e}
+		if err := json.Unmarshal(e.Data.Object, &ce.Charge); err != nil {
+			return err
+		}
+		return fn(ce)
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Secret == "" {
+		http.Error(w, "Webhook signing secret is not configured", http.StatusInternalServerError)
+		return
+	}
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "Unable to read the request body", http.StatusBadRequest)
+		return
+	}
+	err = verify(payload, r.Header.Get(SignatureHeader), h.Secret, h.tolerance(), h.now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var e Event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		http.Error(w, "Invalid event", http.StatusBadRequest)
+		return
+	}
+	for _, fn := range h.callbacks[e.Type] {
+		if err := fn(e); err != nil {
+			http.Error(w, "Unable to handle the event", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) tolerance() time.Duration {
+	if h.Tolerance == 0 {
+		return DefaultTolerance
+	}
+	return h.Tolerance
+}
+
+func (h *Handler) now() time.Time {
+	if h.Now == nil {
+		return time.Now()
+	}
+	return h.Now()
+}
+
+// Verify checks that header, the Stripe-Signature header sent with payload,
+// holds a valid signature of payload made with secret no more than tolerance
+// ago. A tolerance of 0 or less disables the timestamp check. ErrNoSecret is
+// returned if secret is empty, as anyone can make signatures without one.
+func Verify(payload []byte, header, secret string, tolerance time.Duration) error {
+	return verify(payload, header, secret, tolerance, time.Now())
+}
+
+func verify(payload []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	if secret == "" {
+		return ErrNoSecret
+	}
+	var timestamp string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return ErrInvalidHeader
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			sig, err := hex.DecodeString(kv[1])
+			if err != nil {
+				// Stripe may add other signatures in the future, so one that
+				// can't be decoded is skipped rather than rejected.
+				continue
+			}
+			signatures = append(signatures, sig)
+		}
+	}
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidHeader
+	}
+	if len(signatures) == 0 {
+		return ErrNoValidSignature
+	}
+	expected := sign(payload, secret, timestamp)
+	valid := false
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			valid = true
+		}
+	}
+	if !valid {
+		return ErrNoValidSignature
+	}
+	if tolerance > 0 && now.Sub(time.Unix(secs, 0)) > tolerance {
+		return ErrTooOld
+	}
+	return nil
+}
+
+// Sign returns a Stripe-Signature header for payload signed with secret at
+// time t, just like one Stripe would send. It is intended for testing
+// webhook endpoints without Stripe.
+func Sign(payload []byte, secret string, t time.Time) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%x", timestamp, sign(payload, secret, timestamp))
+}
+
+func sign(payload []byte, secret, timestamp string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
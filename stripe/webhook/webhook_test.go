@@ -0,0 +1,221 @@
+package webhook_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joncalhoun/twg/stripe/webhook"
+)
+
+const secret = "whsec_test_secret"
+
+var now = time.Unix(1542650000, 0)
+
+func event(typ, object string) []byte {
+	return []byte(fmt.Sprintf(`{
+  "id": "evt_1EaBcD2eZvKYlo2C",
+  "object": "event",
+  "created": 1542650000,
+  "livemode": false,
+  "type": %q,
+  "data": {
+    "object": %s
+  }
+}`, typ, object))
+}
+
+var chargeFailed = event(webhook.ChargeFailed, `{
+      "id": "ch_1EaBcD2eZvKYlo2CfIPLITs3",
+      "object": "charge",
+      "amount": 5555,
+      "failure_code": "card_declined",
+      "failure_message": "Your card was declined.",
+      "paid": false,
+      "status": "failed"
+    }`)
+
+func TestVerify(t *testing.T) {
+	payload := chargeFailed
+	valid := webhook.Sign(payload, secret, now)
+	tests := map[string]struct {
+		payload []byte
+		header  string
+		want    error
+	}{
+		"valid": {
+			payload: payload,
+			header:  valid,
+		},
+		"extra signatures": {
+			payload: payload,
+			header:  valid + ",v1=deadbeef,v0=6ffbb59b2300aae63f272406069a9788598b792a944a07aba816edb039989a39",
+		},
+		"wrong secret": {
+			payload: payload,
+			header:  webhook.Sign(payload, "whsec_other", now),
+			want:    webhook.ErrNoValidSignature,
+		},
+		"modified payload": {
+			payload: []byte(strings.Replace(string(payload), "5555", "1", 1)),
+			header:  valid,
+			want:    webhook.ErrNoValidSignature,
+		},
+		"no v1 signature": {
+			payload: payload,
+			header:  fmt.Sprintf("t=%d", now.Unix()),
+			want:    webhook.ErrNoValidSignature,
+		},
+		"missing timestamp": {
+			payload: payload,
+			header:  valid[strings.Index(valid, ",")+1:],
+			want:    webhook.ErrInvalidHeader,
+		},
+		"empty header": {
+			payload: payload,
+			want:    webhook.ErrInvalidHeader,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Signatures made at now are long past by the time the test runs,
+			// so the timestamp check is disabled.
+			err := webhook.Verify(tc.payload, tc.header, secret, 0)
+			if err != tc.want {
+				t.Errorf("Verify() err = %v; want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerify_tolerance(t *testing.T) {
+	header := webhook.Sign(chargeFailed, secret, time.Now().Add(-10*time.Minute))
+	if err := webhook.Verify(chargeFailed, header, secret, webhook.DefaultTolerance); err != webhook.ErrTooOld {
+		t.Errorf("Verify() err = %v; want %v", err, webhook.ErrTooOld)
+	}
+	if err := webhook.Verify(chargeFailed, header, secret, time.Hour); err != nil {
+		t.Errorf("Verify() err = %v; want nil", err)
+	}
+}
+
+func TestVerify_noSecret(t *testing.T) {
+	header := webhook.Sign(chargeFailed, "", now)
+	if err := webhook.Verify(chargeFailed, header, "", 0); err != webhook.ErrNoSecret {
+		t.Errorf("Verify() err = %v; want %v", err, webhook.ErrNoSecret)
+	}
+}
+
+func TestHandler_noSecret(t *testing.T) {
+	called := false
+	h := &webhook.Handler{
+		Now: func() time.Time { return now },
+	}
+	h.OnChargeFailed(func(e webhook.ChargeEvent) error {
+		called = true
+		return nil
+	})
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(chargeFailed)))
+	r.Header.Set(webhook.SignatureHeader, webhook.Sign(chargeFailed, "", now))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d; want %d", w.Code, http.StatusInternalServerError)
+	}
+	if called {
+		t.Errorf("callback was called; want forged events to be rejected")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var got []string
+	h := &webhook.Handler{
+		Secret: secret,
+		Now:    func() time.Time { return now },
+	}
+	h.OnChargeFailed(func(e webhook.ChargeEvent) error {
+		got = append(got, fmt.Sprintf("%s %s %s", e.Type, e.Charge.ID, e.Charge.FailureCode))
+		return nil
+	})
+	h.OnChargeSucceeded(func(e webhook.ChargeEvent) error {
+		return errors.New("database is down")
+	})
+	h.OnCustomerDeleted(func(e webhook.CustomerEvent) error {
+		got = append(got, fmt.Sprintf("%s %s", e.Type, e.Customer.ID))
+		return nil
+	})
+
+	customerDeleted := event(webhook.CustomerDeleted, `{"id": "cus_DzaWOOYfMR1uaA", "object": "customer", "deleted": true}`)
+	chargeSucceeded := event(webhook.ChargeSucceeded, `{"id": "ch_1", "object": "charge", "status": "succeeded"}`)
+	unhandled := event("invoice.created", `{"id": "in_1", "object": "invoice"}`)
+	tests := map[string]struct {
+		method  string
+		payload []byte
+		header  string
+		status  int
+		want    []string
+	}{
+		"charge failed": {
+			payload: chargeFailed,
+			status:  http.StatusOK,
+			want:    []string{"charge.failed ch_1EaBcD2eZvKYlo2CfIPLITs3 card_declined"},
+		},
+		"customer deleted": {
+			payload: customerDeleted,
+			status:  http.StatusOK,
+			want:    []string{"customer.deleted cus_DzaWOOYfMR1uaA"},
+		},
+		"unhandled events are acknowledged": {
+			payload: unhandled,
+			status:  http.StatusOK,
+		},
+		"callback errors": {
+			payload: chargeSucceeded,
+			status:  http.StatusInternalServerError,
+		},
+		"invalid signature": {
+			payload: chargeFailed,
+			header:  webhook.Sign(chargeFailed, "whsec_other", now),
+			status:  http.StatusBadRequest,
+		},
+		"expired signature": {
+			payload: chargeFailed,
+			header:  webhook.Sign(chargeFailed, secret, now.Add(-time.Hour)),
+			status:  http.StatusBadRequest,
+		},
+		"invalid JSON": {
+			payload: []byte(`{"id": `),
+			status:  http.StatusBadRequest,
+		},
+		"GET requests": {
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got = nil
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			header := tc.header
+			if header == "" {
+				header = webhook.Sign(tc.payload, secret, now)
+			}
+			r := httptest.NewRequest(method, "/stripe/webhook", strings.NewReader(string(tc.payload)))
+			r.Header.Set(webhook.SignatureHeader, header)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tc.status {
+				t.Errorf("status = %d; want %d", w.Code, tc.status)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("callbacks = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}